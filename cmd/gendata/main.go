@@ -12,6 +12,8 @@ import (
 
 	"github.com/IBM/sarama"
 
+	"kafka-topic-monitor/pkg/config"
+	"kafka-topic-monitor/pkg/kafka"
 	. "kafka-topic-monitor/pkg/logger"
 )
 
@@ -23,6 +25,9 @@ type Config struct {
 	EndDate       string
 	MaxMessages   int
 	TopicPrefix   string
+	Client        config.ClientConfig
+	TLS           config.TLSConfig
+	SASL          config.SASLConfig
 }
 
 type Message struct {
@@ -46,7 +51,7 @@ func main() {
 	}
 
 	// Create Kafka client
-	client, admin, producer, err := setupKafka(config.KafkaBrokers)
+	client, admin, producer, err := setupKafka(config)
 	if err != nil {
 		GetLogger().Fatalf("Error setting up Kafka: %v", err)
 	}
@@ -84,6 +89,18 @@ func parseFlags() *Config {
 	flag.StringVar(&config.EndDate, "end-date", time.Now().Format("2006-01-02"), "End date for message timestamps (YYYY-MM-DD)")
 	flag.IntVar(&config.MaxMessages, "messages", 100, "Maximum number of messages per topic")
 	flag.StringVar(&config.TopicPrefix, "prefix", "test-topic-", "Prefix for topic names")
+	flag.StringVar(&config.Client.KafkaVersion, "kafka-version", "", "Kafka version of brokers, negotiated with brokers if empty")
+	flag.BoolVar(&config.TLS.Enabled, "tls", false, "Enable TLS for broker connections")
+	flag.StringVar(&config.TLS.CAFile, "tls-ca-file", "", "Path to CA certificate file")
+	flag.StringVar(&config.TLS.CertFile, "tls-cert-file", "", "Path to client certificate file")
+	flag.StringVar(&config.TLS.KeyFile, "tls-key-file", "", "Path to client key file")
+	flag.BoolVar(&config.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", false, "Skip verification of broker certificates")
+	flag.StringVar(&config.TLS.ServerName, "tls-server-name", "", "Server name to verify broker certificates against")
+	flag.BoolVar(&config.SASL.Enabled, "sasl", false, "Enable SASL authentication")
+	flag.StringVar(&config.SASL.Mechanism, "sasl-mechanism", "PLAIN", "SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER)")
+	flag.StringVar(&config.SASL.Username, "sasl-username", "", "SASL username")
+	flag.StringVar(&config.SASL.Password, "sasl-password", "", "SASL password")
+	flag.StringVar(&config.SASL.TokenFile, "sasl-token-file", "", "Path to OAUTHBEARER token file")
 
 	flag.Parse()
 
@@ -108,16 +125,30 @@ func parseDateRange(startStr, endStr string) (time.Time, time.Time, error) {
 	return startDate, endDate, nil
 }
 
-func setupKafka(brokers string) (sarama.Client, sarama.ClusterAdmin, sarama.SyncProducer, error) {
+func setupKafka(cfg *Config) (sarama.Client, sarama.ClusterAdmin, sarama.SyncProducer, error) {
 	// Create Sarama configuration
 	config := sarama.NewConfig()
-	config.Version = sarama.V4_0_0_0 // Use appropriate version for your Kafka cluster
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 5
+	if err := kafka.ApplySecurity(config, cfg.TLS, cfg.SASL); err != nil {
+		return nil, nil, nil, err
+	}
 
 	// Split brokers string
-	brokerList := strings.Split(brokers, ",")
+	brokerList := strings.Split(cfg.KafkaBrokers, ",")
+
+	// Use the version of your Kafka cluster or negotiate it with brokers
+	if err := kafka.ApplyClientSettings(config, cfg.Client); err != nil {
+		return nil, nil, nil, err
+	}
+	if kafka.NeedsVersionNegotiation(cfg.Client) {
+		version, err := kafka.NegotiateVersion(brokerList, config)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		config.Version = version
+	}
 
 	// Create client
 	client, err := sarama.NewClient(brokerList, config)
@@ -3,16 +3,25 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
 
+	"kafka-topic-monitor/pkg/audit"
 	"kafka-topic-monitor/pkg/config"
+	"kafka-topic-monitor/pkg/history"
+	"kafka-topic-monitor/pkg/kafka"
 	"kafka-topic-monitor/pkg/logger"
 	"kafka-topic-monitor/pkg/monitor"
 	"kafka-topic-monitor/pkg/monitor/report"
+	"kafka-topic-monitor/pkg/quarantine"
+	"kafka-topic-monitor/pkg/webhook"
 )
 
 func main() {
@@ -24,6 +33,8 @@ func main() {
 		inactivityDays   int
 		logLevel         string
 		addr             string
+		lastReadStrategy string
+		scanInterval     time.Duration
 	)
 
 	// Define command line flags
@@ -31,12 +42,14 @@ func main() {
 	flag.IntVar(&inactivityDays, "inactivity-days", 0, "Number of days to consider a topic inactive")
 	flag.StringVar(&logLevel, "log-level", "", "Log level (debug, info, warn, error)")
 	flag.StringVar(&addr, "addr", "", "HTTP server address")
+	flag.DurationVar(&scanInterval, "scan-interval", 0, "Interval between background scans of topics")
+	flag.StringVar(&lastReadStrategy, "last-read-strategy", "", "Strategy to infer last read time (metadata, record-timestamp, offset-movement)")
 
 	// Parse the command-line flags
 	flag.Parse()
 
 	// First load from file and env.
-	cfg, err := config.LoadConfig(bootstrapServers, inactivityDays, logLevel, addr, *configFile)
+	cfg, err := config.LoadConfig(bootstrapServers, inactivityDays, logLevel, addr, lastReadStrategy, scanInterval, *configFile)
 	if err != nil {
 		logger.GetLogger().Fatalf("Error loading configuration: %v", err)
 	}
@@ -47,17 +60,138 @@ func main() {
 	}
 	logger.NewLogger(os.Stdout, lvl)
 
-	reporter := report.NewCsvReporter()
-	checker := monitor.NewTopicChecker()
-	m, err := monitor.NewMonitor(cfg.BootstrapServers, cfg.InactivityDays, cfg.Addr, checker, reporter)
+	reporters := monitor.NewReporterRegistry()
+	for _, r := range []struct {
+		format      string
+		contentType string
+		reporter    monitor.Reporter
+	}{
+		{"csv", "text/csv", report.NewCsvReporter()},
+		{"json", "application/json", report.NewJson()},
+		{"prometheus", report.PrometheusContentType, report.NewPrometheus()},
+	} {
+		if err := reporters.Register(r.format, r.contentType, r.reporter); err != nil {
+			logger.GetLogger().Fatalf("Error registering reporter: %v", err)
+		}
+	}
+	filter, err := monitor.NewTopicFilter(cfg.TopicFilter.Include, cfg.TopicFilter.Exclude, cfg.TopicFilter.SkipInternal)
 	if err != nil {
-		logger.GetLogger().Fatalf("Error creating monitor: %v", err)
+		logger.GetLogger().Fatalf("Error creating topic filter: %v", err)
+	}
+	rules := make([]monitor.InactivityRule, 0, len(cfg.InactivityRules))
+	for _, ruleConfig := range cfg.InactivityRules {
+		rule, err := monitor.NewInactivityRule(ruleConfig.Name, ruleConfig.Clusters, ruleConfig.Topics, ruleConfig.WriteInactivity, ruleConfig.ReadInactivity)
+		if err != nil {
+			logger.GetLogger().Fatalf("Error creating inactivity rule: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	var historyStore monitor.HistoryStore
+	if cfg.HistoryFile != "" {
+		fileStore, err := history.NewFileStore(cfg.HistoryFile, cfg.HistoryRetention)
+		if err != nil {
+			logger.GetLogger().Fatalf("Error opening history store: %v", err)
+		}
+		defer fileStore.Close()
+		historyStore = fileStore
+	}
+
+	var cleaner *monitor.Cleaner
+	if cfg.Cleanup.Enabled {
+		var auditLog monitor.AuditLog
+		if cfg.Cleanup.AuditFile != "" {
+			fileLog, err := audit.NewFileLog(cfg.Cleanup.AuditFile)
+			if err != nil {
+				logger.GetLogger().Fatalf("Error opening audit log: %v", err)
+			}
+			defer fileLog.Close()
+			auditLog = fileLog
+		}
+		var quarantines monitor.QuarantineStore
+		if cfg.Cleanup.Quarantine.File != "" {
+			fileStore, err := quarantine.NewFileStore(cfg.Cleanup.Quarantine.File)
+			if err != nil {
+				logger.GetLogger().Fatalf("Error opening quarantine store: %v", err)
+			}
+			quarantines = fileStore
+		}
+		cleaner, err = monitor.NewCleaner(cfg.Cleanup.DryRun, cfg.Cleanup.GracePeriod, cfg.Cleanup.Allow, cfg.Cleanup.Deny, cfg.Cleanup.MaxDeletions, cfg.Cleanup.ConfirmationToken, cfg.Cleanup.ArchiveDir, cfg.Cleanup.Mode, cfg.Cleanup.Quarantine.Retention, auditLog, quarantines)
+		if err != nil {
+			logger.GetLogger().Fatalf("Error creating cleaner: %v", err)
+		}
 	}
+
+	clusters := make([]*monitor.Cluster, 0, len(cfg.Clusters))
+	for _, clusterConfig := range cfg.Clusters {
+		cluster, err := newCluster(clusterConfig, cfg)
+		if err != nil {
+			logger.GetLogger().Fatalf("Error connecting to cluster %s: %v", clusterConfig.Name, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	go gracefulShutdown(cancel)
+
+	var notifier monitor.Notifier
+	if len(cfg.Alerting.Webhooks) > 0 {
+		webhooks := make([]webhook.Webhook, 0, len(cfg.Alerting.Webhooks))
+		for _, webhookConfig := range cfg.Alerting.Webhooks {
+			hook, err := webhook.NewWebhook(webhookConfig.Name, webhookConfig.URL, webhookConfig.Format, webhookConfig.RoutingKey, webhookConfig.Events)
+			if err != nil {
+				logger.GetLogger().Fatalf("Error creating webhook: %v", err)
+			}
+			webhooks = append(webhooks, hook)
+		}
+		webhookNotifier := webhook.NewNotifier(webhooks, &http.Client{Timeout: cfg.Alerting.Timeout}, cfg.Alerting.MaxRetries, cfg.Alerting.RetryBackoff)
+		go webhookNotifier.Run(ctx)
+		notifier = webhookNotifier
+	}
+
+	m, err := monitor.NewMonitor(clusters, filter, rules, cfg.ScanInterval, cfg.ScanWorkers, cfg.Addr, reporters, historyStore, cleaner, notifier)
+	if err != nil {
+		logger.GetLogger().Fatalf("Error creating monitor: %v", err)
+	}
 	m.Start(ctx)
 }
 
+// newCluster configures Kafka client and topic checker of the cluster and connects to it
+func newCluster(clusterConfig config.ClusterConfig, cfg *config.Config) (*monitor.Cluster, error) {
+	strategy, err := monitor.NewLastReadStrategy(cfg.LastReadStrategy, cfg.LastRecordWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error creating last read strategy: %w", err)
+	}
+
+	saramaConfig := sarama.NewConfig()
+	if err := kafka.ApplySecurity(saramaConfig, clusterConfig.TLS, clusterConfig.SASL); err != nil {
+		return nil, fmt.Errorf("error configuring Kafka security: %w", err)
+	}
+	if err := kafka.ApplyClientSettings(saramaConfig, clusterConfig.Client); err != nil {
+		return nil, fmt.Errorf("error configuring Kafka client: %w", err)
+	}
+	if kafka.NeedsVersionNegotiation(clusterConfig.Client) {
+		version, err := kafka.NegotiateVersion(clusterConfig.BootstrapServers, saramaConfig)
+		if err != nil {
+			logger.GetLogger().Warnf("Using default Kafka version %s for cluster %s: %v", version, clusterConfig.Name, err)
+		}
+		saramaConfig.Version = version
+	}
+
+	checkerName := cfg.TopicChecker
+	// Timestamps are looked up by ListOffsets v1, added in Kafka 0.10.1.
+	if checkerName == monitor.TopicCheckerListOffsets && !saramaConfig.Version.IsAtLeast(sarama.V0_10_1_0) {
+		logger.GetLogger().Warnf("Kafka version %s of cluster %s doesn't support %s topic checker, using %s", saramaConfig.Version, clusterConfig.Name, checkerName, monitor.TopicCheckerConsume)
+		checkerName = monitor.TopicCheckerConsume
+	}
+	checker, err := monitor.NewTopicCheckerByName(checkerName, strategy, cfg.MaxInFlightPerBroker, cfg.PartitionTimeout, cfg.LastRecordWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error creating topic checker: %w", err)
+	}
+
+	return monitor.NewCluster(clusterConfig.Name, clusterConfig.BootstrapServers, saramaConfig, clusterConfig.InactivityDays, clusterConfig.StorageCostPerGB, checker, cfg.CheckTimeout)
+}
+
 func gracefulShutdown(cancel context.CancelFunc) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/archive"
+	"kafka-topic-monitor/pkg/config"
+	"kafka-topic-monitor/pkg/kafka"
+	. "kafka-topic-monitor/pkg/logger"
+)
+
+type Config struct {
+	KafkaBrokers      string
+	ArchiveDir        string
+	Topic             string
+	ReplicationFactor int
+	Client            config.ClientConfig
+	TLS               config.TLSConfig
+	SASL              config.SASLConfig
+}
+
+func main() {
+	config := parseFlags()
+	if config.ArchiveDir == "" {
+		GetLogger().Fatalf("Archive directory is required")
+	}
+
+	// Stop replaying on interrupt, the topic is left partially restored
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	client, admin, producer, err := setupKafka(config)
+	if err != nil {
+		GetLogger().Fatalf("Error setting up Kafka: %v", err)
+	}
+	defer func() {
+		if err := producer.Close(); err != nil {
+			GetLogger().Warnf("Error closing producer: %v", err)
+		}
+		if err := admin.Close(); err != nil {
+			GetLogger().Warnf("Error closing admin client: %v", err)
+		}
+		if err := client.Close(); err != nil {
+			GetLogger().Warnf("Error closing client: %v", err)
+		}
+	}()
+
+	if _, err := archive.Restore(ctx, admin, producer, config.ArchiveDir, config.Topic, int16(config.ReplicationFactor)); err != nil {
+		GetLogger().Fatalf("Error restoring archive %s: %v", config.ArchiveDir, err)
+	}
+	GetLogger().Infof("Restore completed successfully")
+}
+
+func parseFlags() *Config {
+	config := &Config{}
+
+	flag.StringVar(&config.KafkaBrokers, "brokers", "localhost:9092", "Kafka brokers (comma-separated)")
+	flag.StringVar(&config.ArchiveDir, "archive", "", "Archive directory holding the manifest of the topic")
+	flag.StringVar(&config.Topic, "topic", "", "Name of the restored topic, the archived name if empty")
+	flag.IntVar(&config.ReplicationFactor, "replication-factor", 0, "Replication factor of the restored topic, the archived one if zero")
+	flag.StringVar(&config.Client.KafkaVersion, "kafka-version", "", "Kafka version of brokers, negotiated with brokers if empty")
+	flag.BoolVar(&config.TLS.Enabled, "tls", false, "Enable TLS for broker connections")
+	flag.StringVar(&config.TLS.CAFile, "tls-ca-file", "", "Path to CA certificate file")
+	flag.StringVar(&config.TLS.CertFile, "tls-cert-file", "", "Path to client certificate file")
+	flag.StringVar(&config.TLS.KeyFile, "tls-key-file", "", "Path to client key file")
+	flag.BoolVar(&config.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", false, "Skip verification of broker certificates")
+	flag.StringVar(&config.TLS.ServerName, "tls-server-name", "", "Server name to verify broker certificates against")
+	flag.BoolVar(&config.SASL.Enabled, "sasl", false, "Enable SASL authentication")
+	flag.StringVar(&config.SASL.Mechanism, "sasl-mechanism", "PLAIN", "SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER)")
+	flag.StringVar(&config.SASL.Username, "sasl-username", "", "SASL username")
+	flag.StringVar(&config.SASL.Password, "sasl-password", "", "SASL password")
+	flag.StringVar(&config.SASL.TokenFile, "sasl-token-file", "", "Path to OAUTHBEARER token file")
+
+	flag.Parse()
+
+	return config
+}
+
+func setupKafka(cfg *Config) (sarama.Client, sarama.ClusterAdmin, sarama.SyncProducer, error) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	// Records are replayed into the partitions they were archived from, in order
+	config.Producer.Partitioner = sarama.NewManualPartitioner
+	config.Net.MaxOpenRequests = 1
+	if err := kafka.ApplySecurity(config, cfg.TLS, cfg.SASL); err != nil {
+		return nil, nil, nil, err
+	}
+
+	brokerList := strings.Split(cfg.KafkaBrokers, ",")
+
+	if err := kafka.ApplyClientSettings(config, cfg.Client); err != nil {
+		return nil, nil, nil, err
+	}
+	if kafka.NeedsVersionNegotiation(cfg.Client) {
+		version, err := kafka.NegotiateVersion(brokerList, config)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		config.Version = version
+	}
+
+	client, err := sarama.NewClient(brokerList, config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error creating client: %w", err)
+	}
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, nil, fmt.Errorf("error creating admin: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		admin.Close()
+		client.Close()
+		return nil, nil, nil, fmt.Errorf("error creating producer: %w", err)
+	}
+
+	return client, admin, producer, nil
+}
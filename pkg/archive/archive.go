@@ -0,0 +1,275 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFile is the name of the manifest in the archive directory, it is written last so archives without it are
+// incomplete.
+const ManifestFile = "manifest.json"
+
+var (
+	ErrArchiveExists    = errors.New("archive already exists")
+	ErrChecksumMismatch = errors.New("segment checksum mismatch")
+	ErrUnorderedRecord  = errors.New("records must be written in partition and offset order")
+)
+
+// Header is a header of an archived record.
+type Header struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Record is an archived record of a topic.
+type Record struct {
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+	Key       []byte    `json:"key"`
+	Value     []byte    `json:"value"`
+	Headers   []Header  `json:"headers,omitempty"`
+}
+
+// Segment is a gzip compressed file of consecutive records of a single partition, one JSON record per line.
+type Segment struct {
+	File        string `json:"file"`         // Name of the file in the archive directory.
+	Partition   int32  `json:"partition"`    // Partition of the records.
+	FirstOffset int64  `json:"first_offset"` // Offset of the first record.
+	LastOffset  int64  `json:"last_offset"`  // Offset of the last record.
+	Records     int    `json:"records"`      // Number of records.
+	SHA256      string `json:"sha256"`       // Hex encoded checksum of the file.
+}
+
+// Manifest describes an archived topic and its segments.
+type Manifest struct {
+	Cluster           string            `json:"cluster"`            // Name of the cluster of the topic.
+	Topic             string            `json:"topic"`              // Name of the topic.
+	ArchivedAt        time.Time         `json:"archived_at"`        // Time when archiving started.
+	Partitions        int32             `json:"partitions"`         // Number of partitions of the topic.
+	ReplicationFactor int16             `json:"replication_factor"` // Replication factor of the topic.
+	Configs           map[string]string `json:"configs"`            // Configs set on the topic, defaults are left out.
+	Segments          []Segment         `json:"segments"`           // Segments in partition and offset order.
+}
+
+// Records returns the number of archived records.
+func (m *Manifest) Records() int {
+	var records int
+	for _, segment := range m.Segments {
+		records += segment.Records
+	}
+	return records
+}
+
+// Writer writes records of a topic to segments of up to segmentRecords records and the manifest on Finish.
+type Writer struct {
+	dir            string
+	manifest       Manifest
+	segmentRecords int
+
+	file    *os.File
+	hash    hash.Hash
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+	segment *Segment
+}
+
+// NewWriter creates the archive directory for the topic described by manifest, segments of the manifest are ignored.
+// Existing archives are never overwritten.
+func NewWriter(dir string, manifest Manifest, segmentRecords int) (*Writer, error) {
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveExists, dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory %s: %w", dir, err)
+	}
+
+	manifest.Segments = nil
+	return &Writer{
+		dir:            dir,
+		manifest:       manifest,
+		segmentRecords: max(segmentRecords, 1),
+	}, nil
+}
+
+// Write appends the record to the current segment, records have to be written in partition and offset order.
+func (w *Writer) Write(record Record) error {
+	if w.segment != nil && (record.Partition < w.segment.Partition || record.Partition == w.segment.Partition && record.Offset <= w.segment.LastOffset) {
+		return fmt.Errorf("%w: offset %d of partition %d", ErrUnorderedRecord, record.Offset, record.Partition)
+	}
+	if w.segment != nil && (record.Partition != w.segment.Partition || w.segment.Records >= w.segmentRecords) {
+		if err := w.closeSegment(); err != nil {
+			return err
+		}
+	}
+	if w.segment == nil {
+		if err := w.openSegment(record.Partition, record.Offset); err != nil {
+			return err
+		}
+	}
+
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write record to segment %s: %w", w.segment.File, err)
+	}
+	w.segment.LastOffset = record.Offset
+	w.segment.Records++
+	return nil
+}
+
+// Finish closes the current segment and writes the manifest, the archive is complete once it returns.
+func (w *Writer) Finish() (*Manifest, error) {
+	if w.segment != nil {
+		if err := w.closeSegment(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	// The manifest is renamed into place so readers never see a partial one.
+	tmpName := filepath.Join(w.dir, ManifestFile+".tmp")
+	if err := writeFileSync(tmpName, data); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmpName, filepath.Join(w.dir, ManifestFile)); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	manifest := w.manifest
+	return &manifest, nil
+}
+
+// Abort closes the current segment and removes the archive directory.
+func (w *Writer) Abort() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return os.RemoveAll(w.dir)
+}
+
+// openSegment creates the segment file for records of the partition starting at offset.
+func (w *Writer) openSegment(partition int32, offset int64) error {
+	name := fmt.Sprintf("%05d-%020d.jsonl.gz", partition, offset)
+	file, err := os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create segment %s: %w", name, err)
+	}
+
+	w.file = file
+	w.hash = sha256.New()
+	w.gzip = gzip.NewWriter(io.MultiWriter(file, w.hash))
+	w.encoder = json.NewEncoder(w.gzip)
+	w.segment = &Segment{File: name, Partition: partition, FirstOffset: offset, LastOffset: offset - 1}
+	return nil
+}
+
+// closeSegment flushes the current segment to disk and adds it to the manifest.
+func (w *Writer) closeSegment() error {
+	if err := w.gzip.Close(); err != nil {
+		return fmt.Errorf("failed to compress segment %s: %w", w.segment.File, err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment %s: %w", w.segment.File, err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close segment %s: %w", w.segment.File, err)
+	}
+
+	w.segment.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	w.manifest.Segments = append(w.manifest.Segments, *w.segment)
+	w.file, w.hash, w.gzip, w.encoder, w.segment = nil, nil, nil, nil, nil
+	return nil
+}
+
+// ReadManifest reads the manifest of the archive directory.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of archive %s: %w", dir, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of archive %s: %w", dir, err)
+	}
+	return &manifest, nil
+}
+
+// ReadSegment verifies the checksum of the segment and passes its records to fn in offset order,
+// reading stops at the first error of fn.
+func ReadSegment(dir string, segment Segment, fn func(Record) error) error {
+	name := filepath.Join(dir, segment.File)
+	if err := verifySegment(name, segment.SHA256); err != nil {
+		return err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %s: %w", segment.File, err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("failed to decompress segment %s: %w", segment.File, err)
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var record Record
+		if err := decoder.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to decode record of segment %s: %w", segment.File, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// verifySegment compares the SHA-256 checksum of the file with the expected hex encoded one.
+func verifySegment(name, expected string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %s: %w", filepath.Base(name), err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read segment %s: %w", filepath.Base(name), err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expected {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, filepath.Base(name))
+	}
+	return nil
+}
+
+// writeFileSync writes data to the file and syncs it to disk.
+func writeFileSync(name string, data []byte) error {
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterAndReadSegment(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Partition: 0, Offset: 3, Timestamp: timestamp, Key: []byte("a"), Value: []byte("1"), Headers: []Header{{Key: []byte("trace"), Value: []byte("x")}}},
+		{Partition: 0, Offset: 5, Timestamp: timestamp, Key: []byte("b"), Value: nil},
+		{Partition: 0, Offset: 6, Timestamp: timestamp, Value: []byte{}},
+		{Partition: 2, Offset: 0, Timestamp: timestamp, Value: []byte("3")},
+	}
+
+	writer, err := NewWriter(dir, Manifest{Cluster: "cluster-a", Topic: "orders", Partitions: 3, ReplicationFactor: 2, Configs: map[string]string{"cleanup.policy": "compact"}}, 2)
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, writer.Write(record))
+	}
+	assert.ErrorIs(t, writer.Write(Record{Partition: 1, Offset: 10}), ErrUnorderedRecord)
+	manifest, err := writer.Finish()
+	require.NoError(t, err)
+
+	read, err := ReadManifest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, read)
+	assert.Equal(t, "compact", read.Configs["cleanup.policy"])
+	assert.Equal(t, 4, read.Records())
+	require.Len(t, read.Segments, 3)
+	assert.Equal(t, Segment{File: "00000-00000000000000000003.jsonl.gz", Partition: 0, FirstOffset: 3, LastOffset: 5, Records: 2, SHA256: read.Segments[0].SHA256}, read.Segments[0])
+	assert.Equal(t, int64(6), read.Segments[1].FirstOffset)
+	assert.Equal(t, int32(2), read.Segments[2].Partition)
+
+	var replayed []Record
+	for _, segment := range read.Segments {
+		require.NoError(t, ReadSegment(dir, segment, func(record Record) error {
+			replayed = append(replayed, record)
+			return nil
+		}))
+	}
+	assert.Equal(t, records, replayed)
+
+	// Complete archives are never overwritten.
+	_, err = NewWriter(dir, Manifest{}, 2)
+	assert.ErrorIs(t, err, ErrArchiveExists)
+}
+
+func TestReadSegmentChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(dir, Manifest{Topic: "orders", Partitions: 1}, 10)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(Record{Offset: 0, Value: []byte("1")}))
+	manifest, err := writer.Finish()
+	require.NoError(t, err)
+
+	name := filepath.Join(dir, manifest.Segments[0].File)
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(name, data, 0600))
+
+	err = ReadSegment(dir, manifest.Segments[0], func(Record) error {
+		t.Fatal("records of a corrupted segment must not be read")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestWriterAbort(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	writer, err := NewWriter(dir, Manifest{Topic: "orders", Partitions: 1}, 10)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(Record{Offset: 0, Value: []byte("1")}))
+	require.NoError(t, writer.Abort())
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+
+	. "kafka-topic-monitor/pkg/logger"
+)
+
+// restoreBatchSize is the number of records sent to brokers at once during a restore.
+const restoreBatchSize = 500
+
+// Restore recreates the archived topic with its partitions and configs and replays its records into the partitions they
+// were archived from, keeping keys, values, headers and timestamps. topic overrides the archived topic name and
+// replicationFactor the archived replication factor if they are set. The producer has to be created with the manual
+// partitioner. Offsets of replayed records start at zero. Restore returns the number of replayed records.
+func Restore(ctx context.Context, admin sarama.ClusterAdmin, producer sarama.SyncProducer, dir, topic string, replicationFactor int16) (int, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return 0, err
+	}
+	if topic == "" {
+		topic = manifest.Topic
+	}
+	if replicationFactor <= 0 {
+		replicationFactor = manifest.ReplicationFactor
+	}
+
+	configs := make(map[string]*string, len(manifest.Configs))
+	for name, value := range manifest.Configs {
+		configs[name] = &value
+	}
+	topicDetail := &sarama.TopicDetail{
+		NumPartitions:     manifest.Partitions,
+		ReplicationFactor: replicationFactor,
+		ConfigEntries:     configs,
+	}
+	if err := admin.CreateTopic(topic, topicDetail, false); err != nil {
+		return 0, fmt.Errorf("error creating topic %s: %w", topic, err)
+	}
+	GetLogger().Infof("Created topic %s with %d partitions", topic, manifest.Partitions)
+
+	var (
+		replayed int
+		batch    = make([]*sarama.ProducerMessage, 0, restoreBatchSize)
+	)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := producer.SendMessages(batch); err != nil {
+			return fmt.Errorf("error sending records to topic %s: %w", topic, err)
+		}
+		replayed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for _, segment := range manifest.Segments {
+		err := ReadSegment(dir, segment, func(record Record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			batch = append(batch, newProducerMessage(topic, record))
+			if len(batch) < restoreBatchSize {
+				return nil
+			}
+			return send()
+		})
+		if err != nil {
+			return replayed, err
+		}
+	}
+	if err := send(); err != nil {
+		return replayed, err
+	}
+	GetLogger().Infof("Replayed %d records to topic %s", replayed, topic)
+	return replayed, nil
+}
+
+// newProducerMessage creates a message replaying the record to its partition of the topic.
+func newProducerMessage(topic string, record Record) *sarama.ProducerMessage {
+	message := &sarama.ProducerMessage{
+		Topic:     topic,
+		Partition: record.Partition,
+		Timestamp: record.Timestamp,
+	}
+	// Null keys and values stay null, tombstones of compacted topics are replayed as tombstones.
+	if record.Key != nil {
+		message.Key = sarama.ByteEncoder(record.Key)
+	}
+	if record.Value != nil {
+		message.Value = sarama.ByteEncoder(record.Value)
+	}
+	for _, header := range record.Headers {
+		message.Headers = append(message.Headers, sarama.RecordHeader{Key: header.Key, Value: header.Value})
+	}
+	return message
+}
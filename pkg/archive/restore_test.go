@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdmin records created topics, other calls of the admin client are not implemented.
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+	topics map[string]*sarama.TopicDetail
+}
+
+func (a *fakeAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	if _, exists := a.topics[topic]; exists {
+		return sarama.ErrTopicAlreadyExists
+	}
+	a.topics[topic] = detail
+	return nil
+}
+
+// fakeProducer records sent messages, other calls of the producer are not implemented.
+type fakeProducer struct {
+	sarama.SyncProducer
+	messages []*sarama.ProducerMessage
+	batches  int
+}
+
+func (p *fakeProducer) SendMessages(messages []*sarama.ProducerMessage) error {
+	p.messages = append(p.messages, messages...)
+	p.batches++
+	return nil
+}
+
+func TestRestore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	writer, err := NewWriter(dir, Manifest{Topic: "orders", Partitions: 2, ReplicationFactor: 3, Configs: map[string]string{"cleanup.policy": "compact"}}, 1)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(Record{Partition: 0, Offset: 7, Timestamp: timestamp, Key: []byte("a"), Value: []byte("1"), Headers: []Header{{Key: []byte("trace"), Value: []byte("x")}}}))
+	require.NoError(t, writer.Write(Record{Partition: 1, Offset: 2, Timestamp: timestamp, Key: []byte("b")}))
+	_, err = writer.Finish()
+	require.NoError(t, err)
+
+	admin := &fakeAdmin{topics: make(map[string]*sarama.TopicDetail)}
+	producer := &fakeProducer{}
+	replayed, err := Restore(context.Background(), admin, producer, dir, "orders-restored", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+
+	detail := admin.topics["orders-restored"]
+	require.NotNil(t, detail)
+	assert.Equal(t, int32(2), detail.NumPartitions)
+	assert.Equal(t, int16(1), detail.ReplicationFactor)
+	assert.Equal(t, "compact", *detail.ConfigEntries["cleanup.policy"])
+
+	require.Len(t, producer.messages, 2)
+	assert.Equal(t, 1, producer.batches)
+	message := producer.messages[0]
+	assert.Equal(t, "orders-restored", message.Topic)
+	assert.Equal(t, int32(0), message.Partition)
+	assert.Equal(t, timestamp, message.Timestamp)
+	assert.Equal(t, sarama.ByteEncoder("a"), message.Key)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("x")}}, message.Headers)
+	// Tombstones stay tombstones.
+	assert.Equal(t, int32(1), producer.messages[1].Partition)
+	assert.Nil(t, producer.messages[1].Value)
+
+	// Existing topics are not replayed into.
+	_, err = Restore(context.Background(), admin, producer, dir, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int16(3), admin.topics["orders"].ReplicationFactor)
+	_, err = Restore(context.Background(), admin, producer, dir, "orders", 0)
+	assert.ErrorIs(t, err, sarama.ErrTopicAlreadyExists)
+	assert.Len(t, producer.messages, 4)
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// Entry records a single action taken on a topic, e.g. its deletion by cleanup.
+type Entry struct {
+	ID       string                    `json:"id"`                 // Random ID of the entry, returned when the action is planned.
+	Time     time.Time                 `json:"time"`               // Time when the action was taken.
+	Action   string                    `json:"action"`             // Action taken on the topic.
+	DryRun   bool                      `json:"dry_run"`            // Indicates if the action was only planned.
+	Cluster  string                    `json:"cluster"`            // Name of the cluster of the topic.
+	Topic    string                    `json:"topic"`              // Name of the topic.
+	Reason   string                    `json:"reason,omitempty"`   // Why the action was taken or skipped.
+	Error    string                    `json:"error,omitempty"`    // Error of the action if it failed.
+	Archive  string                    `json:"archive,omitempty"`  // Directory the topic was archived to before the action.
+	Snapshot *report.TopicActivityInfo `json:"snapshot,omitempty"` // Activity of the topic at the time of the action.
+}
+
+// FileLog appends every entry as a JSON line to a file, the file is never rewritten.
+type FileLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileLog opens or creates the audit file.
+func NewFileLog(fileName string) (*FileLog, error) {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file %s: %w", fileName, err)
+	}
+	return &FileLog{file: file}, nil
+}
+
+// Record appends the entry to the file and syncs it to disk before returning.
+func (l *FileLog) Record(entry Entry) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(entry); err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit file.
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestFileLog(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "audit.jsonl")
+	actionTime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	log, err := NewFileLog(fileName)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(Entry{
+		Time:     actionTime,
+		Action:   "delete",
+		DryRun:   true,
+		Cluster:  "cluster-a",
+		Topic:    "orders",
+		Snapshot: &report.TopicActivityInfo{Cluster: "cluster-a", TopicName: "orders", Status: report.StatusIdle},
+	}))
+	require.NoError(t, log.Close())
+
+	// Entries are appended to the existing file.
+	log, err = NewFileLog(fileName)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(Entry{Time: actionTime, Action: "delete", Cluster: "cluster-a", Topic: "payments", Error: "request timed out"}))
+	require.NoError(t, log.Close())
+
+	file, err := os.Open(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, "orders", entries[0].Topic)
+	assert.True(t, entries[0].DryRun)
+	assert.Equal(t, report.StatusIdle, entries[0].Snapshot.Status)
+	assert.Equal(t, "payments", entries[1].Topic)
+	assert.Equal(t, "request timed out", entries[1].Error)
+	assert.Nil(t, entries[1].Snapshot)
+}
@@ -6,26 +6,181 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"strings"
+	"time"
 
 	. "kafka-topic-monitor/pkg/logger"
 )
 
+// DefaultClusterName is the name of the cluster configured by top level settings when no clusters are declared
+const DefaultClusterName = "default"
+
 var (
-	ErrEmptyBootstrapServers = errors.New("empty bootstrap server list")
+	ErrEmptyBootstrapServers   = errors.New("empty bootstrap server list")
+	ErrNonPositiveScanInterval = errors.New("scan interval must be positive")
+	ErrEmptyClusterName        = errors.New("empty cluster name")
+	ErrDuplicateClusterName    = errors.New("duplicate cluster name")
+	ErrEmptyCredentialEnv      = errors.New("empty credential environment variable")
 )
 
 // Config holds the configuration values
 type Config struct {
-	BootstrapServers []string `yaml:"bootstrap_servers"`
-	InactivityDays   int      `yaml:"inactivity_days"`
-	LogLevel         string   `yaml:"log_level"`
-	Addr             string   `yaml:"addr"`
+	BootstrapServers     []string               `yaml:"bootstrap_servers"`
+	InactivityDays       int                    `yaml:"inactivity_days"`
+	StorageCostPerGB     float64                `yaml:"storage_cost_per_gb"`
+	LogLevel             string                 `yaml:"log_level"`
+	Addr                 string                 `yaml:"addr"`
+	LastReadStrategy     string                 `yaml:"last_read_strategy"`
+	TopicChecker         string                 `yaml:"topic_checker"`
+	LastRecordWindow     int64                  `yaml:"last_record_window"`
+	ScanInterval         time.Duration          `yaml:"scan_interval"`
+	ScanWorkers          int                    `yaml:"scan_workers"`
+	MaxInFlightPerBroker int                    `yaml:"max_in_flight_per_broker"`
+	CheckTimeout         time.Duration          `yaml:"check_timeout"`
+	PartitionTimeout     time.Duration          `yaml:"partition_timeout"`
+	HistoryFile          string                 `yaml:"history_file"`
+	HistoryRetention     time.Duration          `yaml:"history_retention"`
+	TLS                  TLSConfig              `yaml:"tls"`
+	SASL                 SASLConfig             `yaml:"sasl"`
+	Client               ClientConfig           `yaml:"client"`
+	Clusters             []ClusterConfig        `yaml:"clusters"`
+	TopicFilter          TopicFilterConfig      `yaml:"topic_filter"`
+	InactivityRules      []InactivityRuleConfig `yaml:"inactivity_rules"`
+	Cleanup              CleanupConfig          `yaml:"cleanup"`
+	Alerting             AlertingConfig         `yaml:"alerting"`
+}
+
+// AlertingConfig holds webhooks receiving state transitions of topics between scans and settings of their delivery.
+type AlertingConfig struct {
+	Timeout      time.Duration   `yaml:"timeout"`
+	MaxRetries   int             `yaml:"max_retries"`
+	RetryBackoff time.Duration   `yaml:"retry_backoff"`
+	Webhooks     []WebhookConfig `yaml:"webhooks"`
+}
+
+// WebhookConfig holds an HTTP endpoint receiving events in one of json, slack or pagerduty formats, all events
+// are sent unless Events lists some of topic-active, topic-inactive, topic-created and topic-deleted.
+type WebhookConfig struct {
+	Name       string   `yaml:"name"`
+	URL        string   `yaml:"url"`
+	Format     string   `yaml:"format"`
+	RoutingKey string   `yaml:"routing_key"`
+	Events     []string `yaml:"events"`
+}
+
+// CleanupConfig holds settings of deleting or quarantining inactive topics, topics are changed only if the cleanup
+// is enabled, dry run is disabled and runs present the confirmation token.
+type CleanupConfig struct {
+	Enabled           bool             `yaml:"enabled"`
+	DryRun            bool             `yaml:"dry_run"`
+	GracePeriod       time.Duration    `yaml:"grace_period"`
+	Allow             []string         `yaml:"allow"`
+	Deny              []string         `yaml:"deny"`
+	MaxDeletions      int              `yaml:"max_deletions"`
+	ConfirmationToken string           `yaml:"confirmation_token"`
+	AuditFile         string           `yaml:"audit_file"`
+	ArchiveDir        string           `yaml:"archive_dir"`
+	Mode              string           `yaml:"mode"`
+	Quarantine        QuarantineConfig `yaml:"quarantine"`
+}
+
+// QuarantineConfig holds settings of the quarantine cleanup mode lowering retention of topics instead of deleting them.
+type QuarantineConfig struct {
+	Retention time.Duration `yaml:"retention"`
+	File      string        `yaml:"file"`
+}
+
+// InactivityRuleConfig maps topics matching any of the patterns to inactivity durations, the first matching rule applies
+// Inactivity applies to both writes and reads unless WriteInactivity or ReadInactivity override it.
+type InactivityRuleConfig struct {
+	Name            string        `yaml:"name"`
+	Clusters        []string      `yaml:"clusters"`
+	Topics          []string      `yaml:"topics"`
+	Inactivity      time.Duration `yaml:"inactivity"`
+	WriteInactivity time.Duration `yaml:"write_inactivity"`
+	ReadInactivity  time.Duration `yaml:"read_inactivity"`
+}
+
+// TopicFilterConfig holds patterns of topics to check, patterns enclosed in slashes are regular expressions, others are globs
+type TopicFilterConfig struct {
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+	SkipInternal bool     `yaml:"skip_internal"` // Skip topics starting with __, which covers topics brokers mark internal
+}
+
+// ClusterConfig holds settings of a named cluster, inactivity days, storage cost, TLS, SASL and client settings default to top level ones
+type ClusterConfig struct {
+	Name             string       `yaml:"name"`
+	BootstrapServers []string     `yaml:"bootstrap_servers"`
+	InactivityDays   int          `yaml:"inactivity_days"`
+	StorageCostPerGB float64      `yaml:"storage_cost_per_gb"`
+	TLS              TLSConfig    `yaml:"tls"`
+	SASL             SASLConfig   `yaml:"sasl"`
+	Client           ClientConfig `yaml:"client"`
+}
+
+// ClientConfig holds Kafka client settings, zero values keep client defaults
+type ClientConfig struct {
+	KafkaVersion            string        `yaml:"kafka_version"`
+	ClientID                string        `yaml:"client_id"`
+	MetadataRefreshInterval time.Duration `yaml:"metadata_refresh_interval"`
+	DialTimeout             time.Duration `yaml:"dial_timeout"`
+	ReadTimeout             time.Duration `yaml:"read_timeout"`
+	WriteTimeout            time.Duration `yaml:"write_timeout"`
+	RetryMax                int           `yaml:"retry_max"`
+	RetryBackoff            time.Duration `yaml:"retry_backoff"`
+	IsolationLevel          string        `yaml:"isolation_level"`
+}
+
+// TLSConfig holds TLS settings of broker connections
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	ServerName         string `yaml:"server_name"`
+}
+
+// SASLConfig holds SASL authentication settings of broker connections,
+// username and password are read from the environment variables named by UsernameEnv and PasswordEnv if set
+type SASLConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Mechanism   string `yaml:"mechanism"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+	TokenFile   string `yaml:"token_file"`
 }
 
 // LoadConfig loads configuration from a YAML file or from environment variables
-func LoadConfig(bootstrapServers string, inactivityDays int, logLvl, addr string, configFileName string) (*Config, error) {
+func LoadConfig(bootstrapServers string, inactivityDays int, logLvl, addr, lastReadStrategy string, scanInterval time.Duration, configFileName string) (*Config, error) {
 	config := &Config{
-		InactivityDays: 7,
+		InactivityDays:       7,
+		ScanInterval:         5 * time.Minute,
+		ScanWorkers:          10,
+		MaxInFlightPerBroker: 5,
+		CheckTimeout:         2 * time.Minute,
+		PartitionTimeout:     30 * time.Second,
+		LastRecordWindow:     500,
+		HistoryRetention:     30 * 24 * time.Hour,
+		TopicFilter: TopicFilterConfig{
+			SkipInternal: true,
+		},
+		Cleanup: CleanupConfig{
+			DryRun:       true,
+			GracePeriod:  30 * 24 * time.Hour,
+			MaxDeletions: 10,
+			Mode:         "delete",
+			Quarantine: QuarantineConfig{
+				Retention: 7 * 24 * time.Hour,
+			},
+		},
+		Alerting: AlertingConfig{
+			Timeout:      10 * time.Second,
+			MaxRetries:   3,
+			RetryBackoff: time.Second,
+		},
 	}
 
 	// Load from file first
@@ -53,9 +208,102 @@ func LoadConfig(bootstrapServers string, inactivityDays int, logLvl, addr string
 		config.Addr = addr
 	}
 
+	if lastReadStrategy != "" {
+		config.LastReadStrategy = lastReadStrategy
+	}
+
+	if scanInterval > 0 {
+		config.ScanInterval = scanInterval
+	}
+
+	if config.ScanInterval <= 0 {
+		return nil, ErrNonPositiveScanInterval
+	}
+
+	if err := config.resolveClusters(); err != nil {
+		return nil, err
+	}
+	config.resolveInactivityRules()
+
 	return config, nil
 }
 
+// resolveClusters declares the default cluster from top level settings if no clusters are declared,
+// fills defaults of declared clusters, reads their credentials from the environment and validates them.
+func (c *Config) resolveClusters() error {
+	if len(c.Clusters) == 0 {
+		c.Clusters = []ClusterConfig{{
+			Name:             DefaultClusterName,
+			BootstrapServers: c.BootstrapServers,
+			TLS:              c.TLS,
+			SASL:             c.SASL,
+		}}
+	}
+
+	names := make(map[string]struct{}, len(c.Clusters))
+	for i := range c.Clusters {
+		cluster := &c.Clusters[i]
+		if cluster.Name == "" {
+			return ErrEmptyClusterName
+		}
+		if _, ok := names[cluster.Name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateClusterName, cluster.Name)
+		}
+		names[cluster.Name] = struct{}{}
+
+		if len(cluster.BootstrapServers) == 0 {
+			return fmt.Errorf("cluster %s: %w", cluster.Name, ErrEmptyBootstrapServers)
+		}
+		if cluster.InactivityDays <= 0 {
+			cluster.InactivityDays = c.InactivityDays
+		}
+		if cluster.StorageCostPerGB <= 0 {
+			cluster.StorageCostPerGB = c.StorageCostPerGB
+		}
+		if cluster.Client == (ClientConfig{}) {
+			cluster.Client = c.Client
+		}
+		if cluster.TLS == (TLSConfig{}) {
+			cluster.TLS = c.TLS
+		}
+		if cluster.SASL == (SASLConfig{}) {
+			cluster.SASL = c.SASL
+		}
+		if err := cluster.SASL.resolveEnv(); err != nil {
+			return fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveEnv reads the username and password from the environment variables named by the settings.
+func (s *SASLConfig) resolveEnv() error {
+	if s.UsernameEnv != "" {
+		if s.Username = os.Getenv(s.UsernameEnv); s.Username == "" {
+			return fmt.Errorf("%w: %s", ErrEmptyCredentialEnv, s.UsernameEnv)
+		}
+	}
+	if s.PasswordEnv != "" {
+		if s.Password = os.Getenv(s.PasswordEnv); s.Password == "" {
+			return fmt.Errorf("%w: %s", ErrEmptyCredentialEnv, s.PasswordEnv)
+		}
+	}
+	return nil
+}
+
+// resolveInactivityRules applies inactivity of rules to writes and reads not having their own inactivity.
+func (c *Config) resolveInactivityRules() {
+	for i := range c.InactivityRules {
+		rule := &c.InactivityRules[i]
+		if rule.WriteInactivity <= 0 {
+			rule.WriteInactivity = rule.Inactivity
+		}
+		if rule.ReadInactivity <= 0 {
+			rule.ReadInactivity = rule.Inactivity
+		}
+	}
+}
+
 func loadFromEnv(config *Config) {
 	// Override with environment variables if they exist
 	if bootstrapServers := os.Getenv("BOOTSTRAP_SERVERS"); bootstrapServers != "" {
@@ -69,9 +317,97 @@ func loadFromEnv(config *Config) {
 		}
 	}
 
+	if storageCost := os.Getenv("STORAGE_COST_PER_GB"); storageCost != "" {
+		var value float64
+		if _, err := fmt.Sscanf(storageCost, "%g", &value); err == nil {
+			config.StorageCostPerGB = value
+		}
+	}
+
 	if listenAddr := os.Getenv("LISTEN_ADDR"); listenAddr != "" {
 		config.Addr = listenAddr
 	}
+
+	if lastReadStrategy := os.Getenv("LAST_READ_STRATEGY"); lastReadStrategy != "" {
+		config.LastReadStrategy = lastReadStrategy
+	}
+
+	if topicChecker := os.Getenv("TOPIC_CHECKER"); topicChecker != "" {
+		config.TopicChecker = topicChecker
+	}
+
+	if scanInterval := os.Getenv("SCAN_INTERVAL"); scanInterval != "" {
+		if value, err := time.ParseDuration(scanInterval); err == nil {
+			config.ScanInterval = value
+		}
+	}
+
+	if scanWorkers := os.Getenv("SCAN_WORKERS"); scanWorkers != "" {
+		var value int
+		if _, err := fmt.Sscanf(scanWorkers, "%d", &value); err == nil {
+			config.ScanWorkers = value
+		}
+	}
+
+	if maxInFlight := os.Getenv("MAX_IN_FLIGHT_PER_BROKER"); maxInFlight != "" {
+		var value int
+		if _, err := fmt.Sscanf(maxInFlight, "%d", &value); err == nil {
+			config.MaxInFlightPerBroker = value
+		}
+	}
+
+	if checkTimeout := os.Getenv("CHECK_TIMEOUT"); checkTimeout != "" {
+		if value, err := time.ParseDuration(checkTimeout); err == nil {
+			config.CheckTimeout = value
+		}
+	}
+
+	if partitionTimeout := os.Getenv("PARTITION_TIMEOUT"); partitionTimeout != "" {
+		if value, err := time.ParseDuration(partitionTimeout); err == nil {
+			config.PartitionTimeout = value
+		}
+	}
+
+	if lastRecordWindow := os.Getenv("LAST_RECORD_WINDOW"); lastRecordWindow != "" {
+		var value int64
+		if _, err := fmt.Sscanf(lastRecordWindow, "%d", &value); err == nil {
+			config.LastRecordWindow = value
+		}
+	}
+
+	if historyFile := os.Getenv("HISTORY_FILE"); historyFile != "" {
+		config.HistoryFile = historyFile
+	}
+
+	if historyRetention := os.Getenv("HISTORY_RETENTION"); historyRetention != "" {
+		if value, err := time.ParseDuration(historyRetention); err == nil {
+			config.HistoryRetention = value
+		}
+	}
+
+	if skipInternal := os.Getenv("SKIP_INTERNAL_TOPICS"); skipInternal != "" {
+		var value bool
+		if _, err := fmt.Sscanf(skipInternal, "%t", &value); err == nil {
+			config.TopicFilter.SkipInternal = value
+		}
+	}
+
+	if kafkaVersion := os.Getenv("KAFKA_VERSION"); kafkaVersion != "" {
+		config.Client.KafkaVersion = kafkaVersion
+	}
+
+	// Keep credentials out of the config file
+	if username := os.Getenv("SASL_USERNAME"); username != "" {
+		config.SASL.Username = username
+	}
+
+	if password := os.Getenv("SASL_PASSWORD"); password != "" {
+		config.SASL.Password = password
+	}
+
+	if token := os.Getenv("CLEANUP_CONFIRMATION_TOKEN"); token != "" {
+		config.Cleanup.ConfirmationToken = token
+	}
 }
 
 func loadFromFile(configFileName string, config *Config) error {
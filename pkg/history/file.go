@@ -0,0 +1,542 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	. "kafka-topic-monitor/pkg/logger"
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// TopicHistory contains the latest activity of a topic seen across all recorded scans.
+type TopicHistory struct {
+	LastWriteTime  time.Time // Latest write time seen in any scan.
+	LastReadTime   time.Time // Latest read time seen in any scan.
+	LastActiveTime time.Time // Time of the latest scan which found the topic active.
+	LastSeenTime   time.Time // Time of the latest scan which found the topic.
+	FirstSeenTime  time.Time // Time of the first scan which found the topic.
+}
+
+// TopicSnapshot contains activity of a topic found by a single scan.
+type TopicSnapshot struct {
+	ScannedAt       time.Time          // Time when the scan started.
+	LastWriteTime   time.Time          // Time when last message was written to any partition.
+	LastReadTime    time.Time          // Time when message was consumed by any consumer group.
+	Status          report.TopicStatus // Status of the topic.
+	Active          bool               // Indicates if the topic was active.
+	PartitionNumber int                // Number of partitions in topic.
+	OldestOffset    int64              // Sum of oldest offsets of all partitions.
+	NewestOffset    int64              // Sum of newest offsets of all partitions, grows with every written message.
+	MessageCount    int64              // Estimated number of messages in all partitions.
+}
+
+// TopicSummary aggregates activity of a topic over scans in a time range.
+type TopicSummary struct {
+	Cluster        string    // Name of the cluster of the topic.
+	TopicName      string    // Name of the topic.
+	FirstSeenTime  time.Time // Time of the first scan in range which found the topic.
+	LastSeenTime   time.Time // Time of the last scan in range which found the topic.
+	Scans          int       // Number of scans in range which found the topic.
+	ActiveScans    int       // Number of scans in range which found the topic active.
+	LastWriteTime  time.Time // Latest write time seen in range.
+	LastReadTime   time.Time // Latest read time seen in range.
+	LastActiveTime time.Time // Time of the latest scan in range which found the topic active.
+}
+
+// historyLine is a line of the history file, either a scan or the topic histories carried over from scans
+// pruned from the file, which is the first line of a pruned file.
+type historyLine struct {
+	ScannedAt    time.Time            `json:"scanned_at"`
+	Listed       []string             `json:"listed_clusters,omitempty"` // Clusters whose topics were listed by the scan.
+	Topics       []topicRecord        `json:"topics,omitempty"`
+	PrunedBefore time.Time            `json:"pruned_before,omitzero"`
+	Histories    []topicHistoryRecord `json:"histories,omitempty"`
+}
+
+// topicRecord is the activity of a topic found by a scan as recorded in the history file.
+type topicRecord struct {
+	Cluster         string
+	TopicName       string
+	LastWriteTime   time.Time
+	LastReadTime    time.Time
+	Status          report.TopicStatus `json:",omitempty"`
+	Active          bool
+	PartitionNumber int
+	OldestOffset    int64
+	NewestOffset    int64
+	MessageCount    int64
+}
+
+// topicHistoryRecord is the history of a topic carried over from pruned scans.
+type topicHistoryRecord struct {
+	Cluster   string
+	TopicName string
+	TopicHistory
+}
+
+// topicKey identifies a topic across clusters.
+type topicKey struct {
+	cluster   string
+	topicName string
+}
+
+// newTopicKey returns the key of the topic, topics without a cluster belong to the default cluster.
+func newTopicKey(cluster, topicName string) topicKey {
+	if cluster == "" {
+		cluster = report.DefaultCluster
+	}
+	return topicKey{cluster: cluster, topicName: topicName}
+}
+
+// keyOf returns the key of the topic.
+func keyOf(info *report.TopicActivityInfo) topicKey {
+	return newTopicKey(info.Cluster, info.TopicName)
+}
+
+// scanPosition is the position of a recorded scan in the file.
+type scanPosition struct {
+	scannedAt time.Time
+	offset    int64
+}
+
+// pruneSlack is the fraction of retention scans may exceed it by before the file is pruned,
+// so the file is rewritten once in a while rather than on every scan.
+const pruneSlack = 10
+
+// FileStore is a history store appending a snapshot of every topic found by a scan as a JSON line to a file.
+// The file is replayed on start, so the activity of topics survives restarts.
+// Positions of scans are kept in memory, so time range queries read only the scans in range.
+// Scans older than retention are pruned from the file, histories of topics are carried over to the pruned file.
+type FileStore struct {
+	fileName  string
+	retention time.Duration
+
+	mu        sync.RWMutex
+	file      *os.File
+	size      int64
+	positions []scanPosition
+	topics    map[topicKey]*TopicHistory
+}
+
+// NewFileStore opens or creates the history file and replays scans recorded in it.
+// Scans older than retention are pruned, non-positive retention keeps all scans.
+func NewFileStore(fileName string, retention time.Duration) (*FileStore, error) {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file %s: %w", fileName, err)
+	}
+
+	store := &FileStore{
+		fileName:  fileName,
+		retention: retention,
+		file:      file,
+		topics:    make(map[topicKey]*TopicHistory),
+	}
+
+	if err := store.replay(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to replay history file %s: %w", fileName, err)
+	}
+	if len(store.positions) > 0 {
+		if err := store.pruneBefore(store.positions[len(store.positions)-1].scannedAt); err != nil {
+			store.file.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// replay rebuilds topic histories and scan positions from scans recorded in the file.
+func (s *FileStore) replay() error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(s.file)
+	for {
+		offset := decoder.InputOffset()
+		var line historyLine
+		err := decoder.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The last line may be partially written if the monitor crashed during append,
+			// cut it off so new scans are appended right after the last complete one.
+			GetLogger().Warnf("Truncating history after %d scans: %v", len(s.positions), err)
+			if err := s.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		if !line.PrunedBefore.IsZero() {
+			s.carryOver(line.Histories)
+			continue
+		}
+		s.update(line.ScannedAt, line.Listed, line.Topics)
+		s.positions = append(s.positions, scanPosition{scannedAt: line.ScannedAt, offset: offset})
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	s.size = info.Size()
+
+	GetLogger().Infof("Replayed %d scans from history, %d topics known", len(s.positions), len(s.topics))
+	return nil
+}
+
+// Record appends snapshots of topics of the scan to the file, updates topic histories and prunes scans
+// older than retention.
+func (s *FileStore) Record(scan *report.Scan) error {
+	line := historyLine{ScannedAt: scan.ScannedAt, Topics: make([]topicRecord, 0, len(scan.Topics))}
+	for _, cluster := range scan.Clusters {
+		if cluster.Error == "" {
+			line.Listed = append(line.Listed, cluster.Name)
+		}
+	}
+	for _, info := range scan.Topics {
+		line.Topics = append(line.Topics, newTopicRecord(info))
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(line); err != nil {
+		return fmt.Errorf("failed to encode scan: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to append scan to history: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync history file: %w", err)
+	}
+	s.positions = append(s.positions, scanPosition{scannedAt: scan.ScannedAt, offset: s.size})
+	s.size += int64(buf.Len())
+	s.update(line.ScannedAt, line.Listed, line.Topics)
+	return s.pruneBefore(scan.ScannedAt)
+}
+
+// Apply fills activity known from history into info, e.g. when Kafka retention has already
+// deleted the last written record or the consumer group was removed.
+func (s *FileStore) Apply(info *report.TopicActivityInfo) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topicHistory, ok := s.topics[keyOf(info)]
+	if !ok {
+		return
+	}
+
+	if topicHistory.LastWriteTime.After(info.LastWriteTime) {
+		info.LastWriteTime = topicHistory.LastWriteTime
+	}
+	if topicHistory.LastReadTime.After(info.LastReadTime) {
+		info.LastReadTime = topicHistory.LastReadTime
+	}
+	if topicHistory.LastActiveTime.After(info.LastActiveTime) {
+		info.LastActiveTime = topicHistory.LastActiveTime
+	}
+	if info.FirstSeenTime.IsZero() || topicHistory.FirstSeenTime.Before(info.FirstSeenTime) {
+		info.FirstSeenTime = topicHistory.FirstSeenTime
+	}
+}
+
+// Topic returns history of the topic of the cluster.
+func (s *FileStore) Topic(cluster, topicName string) (TopicHistory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topicHistory, ok := s.topics[topicKey{cluster: cluster, topicName: topicName}]
+	if !ok {
+		return TopicHistory{}, false
+	}
+	return *topicHistory, true
+}
+
+// TopicSnapshots returns activity of the topic of the cluster found by scans started in [from, to].
+func (s *FileStore) TopicSnapshots(cluster, topicName string, from, to time.Time) ([]TopicSnapshot, error) {
+	key := topicKey{cluster: cluster, topicName: topicName}
+	snapshots := make([]TopicSnapshot, 0)
+	err := s.scans(from, to, func(line *historyLine) {
+		for _, topic := range line.Topics {
+			if newTopicKey(topic.Cluster, topic.TopicName) == key {
+				snapshots = append(snapshots, topic.snapshot(line.ScannedAt))
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// Summary aggregates activity of all topics found by scans started in [from, to], sorted by cluster and topic name.
+func (s *FileStore) Summary(from, to time.Time) ([]TopicSummary, error) {
+	summaries := make(map[topicKey]*TopicSummary)
+	err := s.scans(from, to, func(line *historyLine) {
+		for _, topic := range line.Topics {
+			key := newTopicKey(topic.Cluster, topic.TopicName)
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &TopicSummary{
+					Cluster:       key.cluster,
+					TopicName:     key.topicName,
+					FirstSeenTime: line.ScannedAt,
+				}
+				summaries[key] = summary
+			}
+
+			summary.LastSeenTime = line.ScannedAt
+			summary.Scans++
+			if topic.Active {
+				summary.ActiveScans++
+				summary.LastActiveTime = line.ScannedAt
+			}
+			if topic.LastWriteTime.After(summary.LastWriteTime) {
+				summary.LastWriteTime = topic.LastWriteTime
+			}
+			if topic.LastReadTime.After(summary.LastReadTime) {
+				summary.LastReadTime = topic.LastReadTime
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TopicSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		return result[i].TopicName < result[j].TopicName
+	})
+	return result, nil
+}
+
+// Close closes the history file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// scans reads scans started in [from, to] from the file in the order they were recorded and passes them to fn.
+func (s *FileStore) scans(from, to time.Time, fn func(*historyLine)) error {
+	s.mu.RLock()
+	first := sort.Search(len(s.positions), func(i int) bool {
+		return !s.positions[i].scannedAt.Before(from)
+	})
+	if first == len(s.positions) {
+		s.mu.RUnlock()
+		return nil
+	}
+	start, end := s.positions[first].offset, s.size
+	// Read with a separate file handle, so appends are not blocked by queries. It is opened under the lock,
+	// so the positions belong to the opened file even if the file is pruned meanwhile.
+	file, err := os.Open(s.fileName)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %w", s.fileName, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek history file %s: %w", s.fileName, err)
+	}
+
+	decoder := json.NewDecoder(io.LimitReader(file, end-start))
+	for {
+		var line historyLine
+		err := decoder.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read history file %s: %w", s.fileName, err)
+		}
+		if line.ScannedAt.After(to) {
+			return nil
+		}
+		fn(&line)
+	}
+}
+
+// pruneBefore rewrites the file without scans older than retention before now once they exceed retention by
+// a fraction of it, histories of all topics are written first so they survive pruning. Callers must hold the lock.
+func (s *FileStore) pruneBefore(now time.Time) error {
+	if s.retention <= 0 || len(s.positions) == 0 {
+		return nil
+	}
+	cutoff := now.Add(-s.retention)
+	if !s.positions[0].scannedAt.Before(cutoff.Add(-s.retention / pruneSlack)) {
+		return nil
+	}
+	first := sort.Search(len(s.positions), func(i int) bool {
+		return !s.positions[i].scannedAt.Before(cutoff)
+	})
+	start := s.size
+	if first < len(s.positions) {
+		start = s.positions[first].offset
+	}
+
+	header := historyLine{PrunedBefore: cutoff, Histories: make([]topicHistoryRecord, 0, len(s.topics))}
+	for key, topicHistory := range s.topics {
+		header.Histories = append(header.Histories, topicHistoryRecord{Cluster: key.cluster, TopicName: key.topicName, TopicHistory: *topicHistory})
+	}
+	sort.Slice(header.Histories, func(i, j int) bool {
+		if header.Histories[i].Cluster != header.Histories[j].Cluster {
+			return header.Histories[i].Cluster < header.Histories[j].Cluster
+		}
+		return header.Histories[i].TopicName < header.Histories[j].TopicName
+	})
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(header); err != nil {
+		return fmt.Errorf("failed to encode topic histories: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.fileName), filepath.Base(s.fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to prune history file %s: %w", s.fileName, err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := s.writePruned(tmp, buf.Bytes(), start); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to prune history file %s: %w", s.fileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to prune history file %s: %w", s.fileName, err)
+	}
+	if err := os.Rename(tmp.Name(), s.fileName); err != nil {
+		return fmt.Errorf("failed to prune history file %s: %w", s.fileName, err)
+	}
+
+	file, err := os.OpenFile(s.fileName, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen history file %s: %w", s.fileName, err)
+	}
+	s.file.Close()
+	s.file = file
+
+	shift := int64(buf.Len()) - start
+	positions := make([]scanPosition, 0, len(s.positions)-first)
+	for _, position := range s.positions[first:] {
+		positions = append(positions, scanPosition{scannedAt: position.scannedAt, offset: position.offset + shift})
+	}
+	GetLogger().Infof("Pruned %d scans older than %s from history", first, cutoff.Format(time.RFC3339))
+	s.positions = positions
+	s.size += shift
+	return nil
+}
+
+// writePruned writes the header followed by scans recorded from offset start of the file to w.
+func (s *FileStore) writePruned(w *os.File, header []byte, start int64) error {
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(s.file, start, s.size-start)); err != nil {
+		return err
+	}
+	return w.Sync()
+}
+
+// carryOver merges histories of topics carried over from pruned scans, callers must hold the lock.
+func (s *FileStore) carryOver(histories []topicHistoryRecord) {
+	for _, record := range histories {
+		topicHistory := record.TopicHistory
+		s.topics[newTopicKey(record.Cluster, record.TopicName)] = &topicHistory
+	}
+}
+
+// update merges activity of topics found by the scan into topic histories and forgets topics of listed clusters
+// missing from the scan, so a topic created again with the same name starts a new history. Callers must hold the lock.
+func (s *FileStore) update(scannedAt time.Time, listed []string, topics []topicRecord) {
+	found := make(map[topicKey]struct{}, len(topics))
+	for _, topic := range topics {
+		key := newTopicKey(topic.Cluster, topic.TopicName)
+		found[key] = struct{}{}
+		topicHistory, ok := s.topics[key]
+		if !ok {
+			topicHistory = &TopicHistory{}
+			s.topics[key] = topicHistory
+		}
+
+		if topic.LastWriteTime.After(topicHistory.LastWriteTime) {
+			topicHistory.LastWriteTime = topic.LastWriteTime
+		}
+		if topic.LastReadTime.After(topicHistory.LastReadTime) {
+			topicHistory.LastReadTime = topic.LastReadTime
+		}
+		if topic.Active && scannedAt.After(topicHistory.LastActiveTime) {
+			topicHistory.LastActiveTime = scannedAt
+		}
+		if scannedAt.After(topicHistory.LastSeenTime) {
+			topicHistory.LastSeenTime = scannedAt
+		}
+		if topicHistory.FirstSeenTime.IsZero() || scannedAt.Before(topicHistory.FirstSeenTime) {
+			topicHistory.FirstSeenTime = scannedAt
+		}
+	}
+
+	listedClusters := make(map[string]struct{}, len(listed))
+	for _, cluster := range listed {
+		listedClusters[newTopicKey(cluster, "").cluster] = struct{}{}
+	}
+	for key := range s.topics {
+		if _, ok := listedClusters[key.cluster]; !ok {
+			continue
+		}
+		if _, ok := found[key]; !ok {
+			delete(s.topics, key)
+		}
+	}
+}
+
+// newTopicRecord records activity of the topic with offsets summed over its partitions.
+func newTopicRecord(info *report.TopicActivityInfo) topicRecord {
+	record := topicRecord{
+		Cluster:         info.Cluster,
+		TopicName:       info.TopicName,
+		LastWriteTime:   info.LastWriteTime,
+		LastReadTime:    info.LastReadTime,
+		Status:          info.Status,
+		Active:          info.Active,
+		PartitionNumber: info.PartitionNumber,
+	}
+	for _, partition := range info.Partitions {
+		record.OldestOffset += partition.OldestOffset
+		record.NewestOffset += partition.NewestOffset
+		record.MessageCount += partition.MessageCount
+	}
+	return record
+}
+
+// snapshot returns the activity of the topic found by the scan started at scannedAt.
+func (r topicRecord) snapshot(scannedAt time.Time) TopicSnapshot {
+	return TopicSnapshot{
+		ScannedAt:       scannedAt,
+		LastWriteTime:   r.LastWriteTime,
+		LastReadTime:    r.LastReadTime,
+		Status:          r.Status,
+		Active:          r.Active,
+		PartitionNumber: r.PartitionNumber,
+		OldestOffset:    r.OldestOffset,
+		NewestOffset:    r.NewestOffset,
+		MessageCount:    r.MessageCount,
+	}
+}
@@ -0,0 +1,325 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestFileStore(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	firstScan := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	secondScan := firstScan.Add(time.Hour)
+	writeTime := time.Date(2023, 9, 30, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(fileName, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: firstScan,
+		Topics: []*report.TopicActivityInfo{
+			{TopicName: "topic-a", LastWriteTime: writeTime, Active: true},
+		},
+	}))
+	// Retention deleted the last record, the topic looks empty now.
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: secondScan,
+		Topics: []*report.TopicActivityInfo{
+			{TopicName: "topic-a"},
+		},
+	}))
+	require.NoError(t, store.Close())
+
+	// History survives reopening the store.
+	store, err = NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	topicHistory, ok := store.Topic(report.DefaultCluster, "topic-a")
+	require.True(t, ok)
+	assert.Equal(t, TopicHistory{
+		LastWriteTime:  writeTime,
+		LastActiveTime: firstScan,
+		LastSeenTime:   secondScan,
+		FirstSeenTime:  firstScan,
+	}, topicHistory)
+
+	info := &report.TopicActivityInfo{TopicName: "topic-a"}
+	store.Apply(info)
+	assert.Equal(t, writeTime, info.LastWriteTime)
+	assert.Equal(t, firstScan, info.LastActiveTime)
+	assert.Equal(t, firstScan, info.FirstSeenTime)
+	assert.True(t, info.LastReadTime.IsZero())
+
+	_, ok = store.Topic(report.DefaultCluster, "topic-b")
+	assert.False(t, ok)
+}
+
+func TestFileStoreTruncatesPartialScan(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	scannedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: scannedAt,
+		Topics:    []*report.TopicActivityInfo{{TopicName: "topic-a", Active: true}},
+	}))
+	require.NoError(t, store.Close())
+
+	// Simulate crash in the middle of append.
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"scanned_at":"2023-10-01T13:00:00Z","topics":[{"TopicName":"top`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: scannedAt.Add(2 * time.Hour),
+		Topics:    []*report.TopicActivityInfo{{TopicName: "topic-b", Active: true}},
+	}))
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, ok := store.Topic(report.DefaultCluster, "topic-a")
+	assert.True(t, ok)
+	_, ok = store.Topic(report.DefaultCluster, "topic-b")
+	assert.True(t, ok)
+}
+
+func TestFileStoreTimeRangeQueries(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	writeTime := start.Add(-time.Hour)
+
+	store, err := NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	for day := range 4 {
+		scan := &report.Scan{
+			ScannedAt: start.Add(time.Duration(day) * 24 * time.Hour),
+			Topics: []*report.TopicActivityInfo{
+				{
+					TopicName:       "topic-a",
+					LastWriteTime:   writeTime,
+					Active:          day < 2,
+					PartitionNumber: 2,
+					Partitions: []report.PartitionActivityInfo{
+						{Partition: 0, OldestOffset: 1, NewestOffset: 10, MessageCount: 9},
+						{Partition: 1, OldestOffset: 2, NewestOffset: 5, MessageCount: 3},
+					},
+				},
+			},
+		}
+		if day%2 == 1 {
+			scan.Topics = append(scan.Topics, &report.TopicActivityInfo{TopicName: "topic-b"})
+		}
+		require.NoError(t, store.Record(scan))
+	}
+
+	snapshots, err := store.TopicSnapshots(report.DefaultCluster, "topic-a", start.Add(24*time.Hour), start.Add(2*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []TopicSnapshot{
+		{
+			ScannedAt:       start.Add(24 * time.Hour),
+			LastWriteTime:   writeTime,
+			Active:          true,
+			PartitionNumber: 2,
+			OldestOffset:    3,
+			NewestOffset:    15,
+			MessageCount:    12,
+		},
+		{
+			ScannedAt:       start.Add(2 * 24 * time.Hour),
+			LastWriteTime:   writeTime,
+			Active:          false,
+			PartitionNumber: 2,
+			OldestOffset:    3,
+			NewestOffset:    15,
+			MessageCount:    12,
+		},
+	}, snapshots)
+
+	snapshots, err = store.TopicSnapshots(report.DefaultCluster, "topic-a", start.Add(10*24*time.Hour), start.Add(20*24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	summary, err := store.Summary(time.Time{}, start.Add(10*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []TopicSummary{
+		{
+			Cluster:        report.DefaultCluster,
+			TopicName:      "topic-a",
+			FirstSeenTime:  start,
+			LastSeenTime:   start.Add(3 * 24 * time.Hour),
+			Scans:          4,
+			ActiveScans:    2,
+			LastWriteTime:  writeTime,
+			LastActiveTime: start.Add(24 * time.Hour),
+		},
+		{
+			Cluster:       report.DefaultCluster,
+			TopicName:     "topic-b",
+			FirstSeenTime: start.Add(24 * time.Hour),
+			LastSeenTime:  start.Add(3 * 24 * time.Hour),
+			Scans:         2,
+		},
+	}, summary)
+}
+
+func TestFileStoreClusters(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	scannedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	writeTime := scannedAt.Add(-time.Hour)
+
+	store, err := NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Topics with the same name in different clusters have separate histories.
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: scannedAt,
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "orders", LastWriteTime: writeTime, Active: true},
+			{Cluster: "cluster-b", TopicName: "orders"},
+		},
+	}))
+
+	info := &report.TopicActivityInfo{Cluster: "cluster-b", TopicName: "orders"}
+	store.Apply(info)
+	assert.True(t, info.LastWriteTime.IsZero())
+
+	info = &report.TopicActivityInfo{Cluster: "cluster-a", TopicName: "orders"}
+	store.Apply(info)
+	assert.Equal(t, writeTime, info.LastWriteTime)
+
+	snapshots, err := store.TopicSnapshots("cluster-b", "orders", time.Time{}, scannedAt)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.False(t, snapshots[0].Active)
+
+	summary, err := store.Summary(time.Time{}, scannedAt)
+	require.NoError(t, err)
+	require.Len(t, summary, 2)
+	assert.Equal(t, "cluster-a", summary[0].Cluster)
+	assert.Equal(t, "cluster-b", summary[1].Cluster)
+}
+
+func TestFileStorePrunesScans(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	writeTime := start.Add(-time.Hour)
+
+	store, err := NewFileStore(fileName, 10*24*time.Hour)
+	require.NoError(t, err)
+	for day := range 12 {
+		scan := &report.Scan{
+			ScannedAt: start.Add(time.Duration(day) * 24 * time.Hour),
+			Topics:    []*report.TopicActivityInfo{{TopicName: "topic-a"}},
+		}
+		if day == 0 {
+			scan.Topics = []*report.TopicActivityInfo{{TopicName: "topic-a", LastWriteTime: writeTime, Active: true}}
+		}
+		require.NoError(t, store.Record(scan))
+	}
+
+	// Scans older than retention are kept until they exceed it by the slack.
+	summary, err := store.Summary(time.Time{}, start.Add(20*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, summary, 1)
+	assert.Equal(t, 12, summary[0].Scans)
+
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: start.Add(12 * 24 * time.Hour),
+		Topics:    []*report.TopicActivityInfo{{TopicName: "topic-a"}},
+	}))
+	summary, err = store.Summary(time.Time{}, start.Add(20*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, summary, 1)
+	assert.Equal(t, start.Add(2*24*time.Hour), summary[0].FirstSeenTime)
+	assert.Equal(t, 11, summary[0].Scans)
+	require.NoError(t, store.Close())
+
+	// Histories of topics carried over from pruned scans survive reopening the store.
+	store, err = NewFileStore(fileName, 10*24*time.Hour)
+	require.NoError(t, err)
+	defer store.Close()
+
+	topicHistory, ok := store.Topic(report.DefaultCluster, "topic-a")
+	require.True(t, ok)
+	assert.Equal(t, TopicHistory{
+		LastWriteTime:  writeTime,
+		LastActiveTime: start,
+		LastSeenTime:   start.Add(12 * 24 * time.Hour),
+		FirstSeenTime:  start,
+	}, topicHistory)
+
+	snapshots, err := store.TopicSnapshots(report.DefaultCluster, "topic-a", time.Time{}, start.Add(20*24*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 11)
+}
+
+func TestFileStoreForgetsDeletedTopics(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	writeTime := start.Add(-time.Hour)
+	listed := []report.ClusterInfo{{Name: "cluster-a"}}
+
+	store, err := NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: start,
+		Clusters:  listed,
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "orders", LastWriteTime: writeTime, Active: true},
+			{Cluster: "cluster-b", TopicName: "orders", LastWriteTime: writeTime},
+		},
+	}))
+	// Topics of clusters which failed to be listed are kept.
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: start.Add(time.Hour),
+		Clusters:  []report.ClusterInfo{{Name: "cluster-a"}, {Name: "cluster-b", Error: "kafka: client has run out of available brokers"}},
+	}))
+	_, ok := store.Topic("cluster-b", "orders")
+	assert.True(t, ok)
+	_, ok = store.Topic("cluster-a", "orders")
+	assert.False(t, ok)
+
+	// The topic was created again, it doesn't inherit the history of the deleted one.
+	recreated := start.Add(2 * time.Hour)
+	require.NoError(t, store.Record(&report.Scan{
+		ScannedAt: recreated,
+		Clusters:  listed,
+		Topics:    []*report.TopicActivityInfo{{Cluster: "cluster-a", TopicName: "orders"}},
+	}))
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(fileName, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	topicHistory, ok := store.Topic("cluster-a", "orders")
+	require.True(t, ok)
+	assert.Equal(t, TopicHistory{LastSeenTime: recreated, FirstSeenTime: recreated}, topicHistory)
+
+	info := &report.TopicActivityInfo{Cluster: "cluster-a", TopicName: "orders"}
+	store.Apply(info)
+	assert.True(t, info.LastWriteTime.IsZero())
+	assert.True(t, info.LastActiveTime.IsZero())
+
+	topicHistory, ok = store.Topic("cluster-b", "orders")
+	require.True(t, ok)
+	assert.Equal(t, writeTime, topicHistory.LastWriteTime)
+}
@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/config"
+	. "kafka-topic-monitor/pkg/logger"
+)
+
+// VersionAuto asks to negotiate Kafka version with brokers instead of using a configured one.
+const VersionAuto = "auto"
+
+// Names of isolation levels of reading transactional records.
+const (
+	IsolationReadUncommitted = "read_uncommitted"
+	IsolationReadCommitted   = "read_committed"
+)
+
+// fetchAPIKey is the key of Fetch request in ApiVersions response.
+const fetchAPIKey int16 = 1
+
+// fetchVersions maps the maximum Fetch request version supported by a broker to the oldest
+// Kafka release supporting it, ordered from newest to oldest.
+var fetchVersions = []struct {
+	fetchVersion int16
+	kafkaVersion sarama.KafkaVersion
+}{
+	{17, sarama.V3_9_0_0},
+	{16, sarama.V3_7_0_0},
+	{15, sarama.V3_5_0_0},
+	{13, sarama.V3_1_0_0},
+	{12, sarama.V2_7_0_0},
+	{11, sarama.V2_3_0_0},
+	{10, sarama.V2_1_0_0},
+	{8, sarama.V2_0_0_0},
+	{7, sarama.V1_1_0_0},
+	{6, sarama.V1_0_0_0},
+	{4, sarama.V0_11_0_0},
+	{3, sarama.V0_10_1_0},
+	{2, sarama.V0_10_0_0},
+}
+
+// ApplyClientSettings configures Kafka version, client id, metadata refresh, timeouts, retries and isolation level.
+// Zero values keep sarama defaults, the version is left untouched if it should be negotiated.
+func ApplyClientSettings(saramaConfig *sarama.Config, clientConfig config.ClientConfig) error {
+	if !NeedsVersionNegotiation(clientConfig) {
+		version, err := sarama.ParseKafkaVersion(clientConfig.KafkaVersion)
+		if err != nil {
+			return fmt.Errorf("failed to parse Kafka version: %w", err)
+		}
+		saramaConfig.Version = version
+	}
+
+	if clientConfig.ClientID != "" {
+		saramaConfig.ClientID = clientConfig.ClientID
+	}
+	if clientConfig.MetadataRefreshInterval > 0 {
+		saramaConfig.Metadata.RefreshFrequency = clientConfig.MetadataRefreshInterval
+	}
+	if clientConfig.DialTimeout > 0 {
+		saramaConfig.Net.DialTimeout = clientConfig.DialTimeout
+	}
+	if clientConfig.ReadTimeout > 0 {
+		saramaConfig.Net.ReadTimeout = clientConfig.ReadTimeout
+	}
+	if clientConfig.WriteTimeout > 0 {
+		saramaConfig.Net.WriteTimeout = clientConfig.WriteTimeout
+	}
+	if clientConfig.RetryMax > 0 {
+		saramaConfig.Metadata.Retry.Max = clientConfig.RetryMax
+		saramaConfig.Admin.Retry.Max = clientConfig.RetryMax
+		saramaConfig.Producer.Retry.Max = clientConfig.RetryMax
+	}
+	if clientConfig.RetryBackoff > 0 {
+		saramaConfig.Metadata.Retry.Backoff = clientConfig.RetryBackoff
+		saramaConfig.Admin.Retry.Backoff = clientConfig.RetryBackoff
+		saramaConfig.Producer.Retry.Backoff = clientConfig.RetryBackoff
+		saramaConfig.Consumer.Retry.Backoff = clientConfig.RetryBackoff
+	}
+	switch clientConfig.IsolationLevel {
+	case "":
+	case IsolationReadUncommitted:
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadUncommitted
+	case IsolationReadCommitted:
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownIsolationLevel, clientConfig.IsolationLevel)
+	}
+
+	return saramaConfig.Validate()
+}
+
+// NeedsVersionNegotiation tells if Kafka version is not configured and should be negotiated with brokers.
+func NeedsVersionNegotiation(clientConfig config.ClientConfig) bool {
+	return clientConfig.KafkaVersion == "" || clientConfig.KafkaVersion == VersionAuto
+}
+
+// NegotiateVersion asks brokers one by one for supported API versions and returns the Kafka version
+// of the first broker which responded.
+func NegotiateVersion(servers []string, saramaConfig *sarama.Config) (sarama.KafkaVersion, error) {
+	probeConfig := *saramaConfig
+	// SASL handshake and authenticate requests of 1.0 are supported by all brokers since then.
+	probeConfig.Version = sarama.V1_0_0_0
+
+	var errs []error
+	for _, server := range servers {
+		version, err := brokerVersion(server, &probeConfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("broker %s: %w", server, err))
+			continue
+		}
+		GetLogger().Infof("Negotiated Kafka version %s with broker %s", version, server)
+		return version, nil
+	}
+	return sarama.DefaultVersion, fmt.Errorf("failed to negotiate Kafka version: %w", errors.Join(errs...))
+}
+
+func brokerVersion(server string, saramaConfig *sarama.Config) (sarama.KafkaVersion, error) {
+	broker := sarama.NewBroker(server)
+	if err := broker.Open(saramaConfig); err != nil {
+		return sarama.KafkaVersion{}, err
+	}
+	defer broker.Close()
+
+	response, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return sarama.KafkaVersion{}, err
+	}
+	if kerr := sarama.KError(response.ErrorCode); kerr != sarama.ErrNoError {
+		return sarama.KafkaVersion{}, kerr
+	}
+
+	for _, apiKey := range response.ApiKeys {
+		if apiKey.ApiKey == fetchAPIKey {
+			return versionFromFetch(apiKey.MaxVersion)
+		}
+	}
+	return sarama.KafkaVersion{}, errors.New("fetch API is not supported")
+}
+
+// versionFromFetch returns the oldest Kafka version supporting the Fetch request version.
+// Fetch versions older than the ones of Kafka 0.10.0, which introduced ApiVersions, are unknown.
+func versionFromFetch(maxFetchVersion int16) (sarama.KafkaVersion, error) {
+	for _, v := range fetchVersions {
+		if maxFetchVersion >= v.fetchVersion {
+			return v.kafkaVersion, nil
+		}
+	}
+	return sarama.KafkaVersion{}, fmt.Errorf("%w: %d", ErrUnknownFetchVersion, maxFetchVersion)
+}
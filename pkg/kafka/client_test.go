@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/config"
+)
+
+func TestApplyClientSettings(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	err := ApplyClientSettings(saramaConfig, config.ClientConfig{
+		KafkaVersion:            "2.8.1",
+		ClientID:                "topic-monitor",
+		MetadataRefreshInterval: time.Minute,
+		DialTimeout:             5 * time.Second,
+		ReadTimeout:             10 * time.Second,
+		WriteTimeout:            15 * time.Second,
+		RetryMax:                7,
+		RetryBackoff:            time.Second,
+		IsolationLevel:          IsolationReadCommitted,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, sarama.V2_8_1_0, saramaConfig.Version)
+	assert.Equal(t, "topic-monitor", saramaConfig.ClientID)
+	assert.Equal(t, time.Minute, saramaConfig.Metadata.RefreshFrequency)
+	assert.Equal(t, 5*time.Second, saramaConfig.Net.DialTimeout)
+	assert.Equal(t, 10*time.Second, saramaConfig.Net.ReadTimeout)
+	assert.Equal(t, 15*time.Second, saramaConfig.Net.WriteTimeout)
+	assert.Equal(t, 7, saramaConfig.Metadata.Retry.Max)
+	assert.Equal(t, 7, saramaConfig.Admin.Retry.Max)
+	assert.Equal(t, time.Second, saramaConfig.Consumer.Retry.Backoff)
+	assert.Equal(t, sarama.ReadCommitted, saramaConfig.Consumer.IsolationLevel)
+}
+
+func TestApplyClientSettingsDefaults(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	defaults := sarama.NewConfig()
+	require.NoError(t, ApplyClientSettings(saramaConfig, config.ClientConfig{KafkaVersion: VersionAuto}))
+
+	assert.Equal(t, defaults.Version, saramaConfig.Version)
+	assert.Equal(t, defaults.ClientID, saramaConfig.ClientID)
+	assert.Equal(t, defaults.Net.DialTimeout, saramaConfig.Net.DialTimeout)
+	assert.Equal(t, defaults.Metadata.Retry.Max, saramaConfig.Metadata.Retry.Max)
+	assert.Equal(t, defaults.Consumer.IsolationLevel, saramaConfig.Consumer.IsolationLevel)
+
+	err := ApplyClientSettings(sarama.NewConfig(), config.ClientConfig{KafkaVersion: "not-a-version"})
+	assert.Error(t, err)
+
+	err = ApplyClientSettings(sarama.NewConfig(), config.ClientConfig{KafkaVersion: VersionAuto, IsolationLevel: "serializable"})
+	assert.ErrorIs(t, err, ErrUnknownIsolationLevel)
+}
+
+func TestVersionFromFetch(t *testing.T) {
+	tests := []struct {
+		fetchVersion int16
+		expected     sarama.KafkaVersion
+	}{
+		{18, sarama.V3_9_0_0},
+		{17, sarama.V3_9_0_0},
+		{14, sarama.V3_1_0_0},
+		{12, sarama.V2_7_0_0},
+		{9, sarama.V2_0_0_0},
+		{6, sarama.V1_0_0_0},
+		{5, sarama.V0_11_0_0},
+		{4, sarama.V0_11_0_0},
+		{3, sarama.V0_10_1_0},
+		{2, sarama.V0_10_0_0},
+	}
+
+	for _, test := range tests {
+		version, err := versionFromFetch(test.fetchVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, version, "fetch version %d", test.fetchVersion)
+	}
+
+	_, err := versionFromFetch(1)
+	assert.ErrorIs(t, err, ErrUnknownFetchVersion)
+}
@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/config"
+)
+
+var (
+	ErrUnsupportedSASLMechanism = errors.New("unsupported SASL mechanism")
+	ErrMissingSASLCredentials   = errors.New("missing SASL credentials")
+	ErrUnknownIsolationLevel    = errors.New("unknown isolation level")
+	ErrUnknownFetchVersion      = errors.New("unknown Fetch request version")
+)
+
+// ApplySecurity configures TLS and SASL of broker connections
+func ApplySecurity(saramaConfig *sarama.Config, tlsConfig config.TLSConfig, saslConfig config.SASLConfig) error {
+	if err := applyTLS(saramaConfig, tlsConfig); err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	if err := applySASL(saramaConfig, saslConfig); err != nil {
+		return fmt.Errorf("failed to configure SASL: %w", err)
+	}
+	return nil
+}
+
+func applyTLS(saramaConfig *sarama.Config, tlsConfig config.TLSConfig) error {
+	saramaConfig.Net.TLS.Enable = tlsConfig.Enabled
+	if !tlsConfig.Enabled {
+		return nil
+	}
+
+	clientTLSConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+		ServerName:         tlsConfig.ServerName,
+	}
+
+	if tlsConfig.CAFile != "" {
+		caCert, err := os.ReadFile(tlsConfig.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file %s: %w", tlsConfig.CAFile, err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("no certificates found in CA file %s", tlsConfig.CAFile)
+		}
+		clientTLSConfig.RootCAs = caCertPool
+	}
+
+	if tlsConfig.CertFile != "" || tlsConfig.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		clientTLSConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	saramaConfig.Net.TLS.Config = clientTLSConfig
+	return nil
+}
+
+func applySASL(saramaConfig *sarama.Config, saslConfig config.SASLConfig) error {
+	saramaConfig.Net.SASL.Enable = saslConfig.Enabled
+	if !saslConfig.Enabled {
+		return nil
+	}
+
+	saramaConfig.Net.SASL.Handshake = true
+	mechanism := sarama.SASLMechanism(strings.ToUpper(saslConfig.Mechanism))
+	switch mechanism {
+	case "", sarama.SASLTypePlaintext:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case sarama.SASLTypeSCRAMSHA256:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: sha256HashGenerator}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: sha512HashGenerator}
+		}
+	case sarama.SASLTypeOAuth:
+		if saslConfig.TokenFile == "" {
+			return fmt.Errorf("%w: token file is required for %s", ErrMissingSASLCredentials, mechanism)
+		}
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		saramaConfig.Net.SASL.TokenProvider = &fileTokenProvider{fileName: saslConfig.TokenFile}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedSASLMechanism, saslConfig.Mechanism)
+	}
+
+	if saslConfig.Username == "" {
+		return fmt.Errorf("%w: username is required for %s", ErrMissingSASLCredentials, saramaConfig.Net.SASL.Mechanism)
+	}
+	saramaConfig.Net.SASL.User = saslConfig.Username
+	saramaConfig.Net.SASL.Password = saslConfig.Password
+	return nil
+}
+
+// fileTokenProvider reads OAUTHBEARER token from a file on every authentication, so rotated tokens are picked up.
+type fileTokenProvider struct {
+	fileName string
+}
+
+func (p *fileTokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := os.ReadFile(p.fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file %s: %w", p.fileName, err)
+	}
+	return &sarama.AccessToken{Token: strings.TrimSpace(string(token))}, nil
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/config"
+)
+
+func TestApplySecurityDisabled(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	require.NoError(t, ApplySecurity(saramaConfig, config.TLSConfig{}, config.SASLConfig{}))
+	assert.False(t, saramaConfig.Net.TLS.Enable)
+	assert.False(t, saramaConfig.Net.SASL.Enable)
+}
+
+func TestApplySecurityTLS(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	err := ApplySecurity(saramaConfig, config.TLSConfig{Enabled: true, InsecureSkipVerify: true, ServerName: "kafka"}, config.SASLConfig{})
+	require.NoError(t, err)
+	assert.True(t, saramaConfig.Net.TLS.Enable)
+	assert.True(t, saramaConfig.Net.TLS.Config.InsecureSkipVerify)
+	assert.Equal(t, "kafka", saramaConfig.Net.TLS.Config.ServerName)
+
+	err = ApplySecurity(sarama.NewConfig(), config.TLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}, config.SASLConfig{})
+	assert.Error(t, err)
+}
+
+func TestApplySecuritySASL(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0600))
+
+	tests := []struct {
+		name              string
+		sasl              config.SASLConfig
+		expectedMechanism sarama.SASLMechanism
+		expectedErr       error
+	}{
+		{
+			name:              "plain is default",
+			sasl:              config.SASLConfig{Enabled: true, Username: "user", Password: "password"},
+			expectedMechanism: sarama.SASLTypePlaintext,
+		},
+		{
+			name:              "scram sha 256",
+			sasl:              config.SASLConfig{Enabled: true, Mechanism: "scram-sha-256", Username: "user", Password: "password"},
+			expectedMechanism: sarama.SASLTypeSCRAMSHA256,
+		},
+		{
+			name:              "scram sha 512",
+			sasl:              config.SASLConfig{Enabled: true, Mechanism: "SCRAM-SHA-512", Username: "user", Password: "password"},
+			expectedMechanism: sarama.SASLTypeSCRAMSHA512,
+		},
+		{
+			name:              "oauthbearer",
+			sasl:              config.SASLConfig{Enabled: true, Mechanism: "OAUTHBEARER", TokenFile: tokenFile},
+			expectedMechanism: sarama.SASLTypeOAuth,
+		},
+		{
+			name:        "oauthbearer without token file",
+			sasl:        config.SASLConfig{Enabled: true, Mechanism: "OAUTHBEARER"},
+			expectedErr: ErrMissingSASLCredentials,
+		},
+		{
+			name:        "missing username",
+			sasl:        config.SASLConfig{Enabled: true, Mechanism: "PLAIN"},
+			expectedErr: ErrMissingSASLCredentials,
+		},
+		{
+			name:        "unsupported mechanism",
+			sasl:        config.SASLConfig{Enabled: true, Mechanism: "GSSAPI", Username: "user"},
+			expectedErr: ErrUnsupportedSASLMechanism,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saramaConfig := sarama.NewConfig()
+			err := ApplySecurity(saramaConfig, config.TLSConfig{}, tt.sasl)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, saramaConfig.Net.SASL.Enable)
+			assert.Equal(t, tt.expectedMechanism, saramaConfig.Net.SASL.Mechanism)
+			assert.NoError(t, saramaConfig.Validate())
+		})
+	}
+
+	// Token is read from file on demand.
+	saramaConfig := sarama.NewConfig()
+	require.NoError(t, ApplySecurity(saramaConfig, config.TLSConfig{}, config.SASLConfig{Enabled: true, Mechanism: "OAUTHBEARER", TokenFile: tokenFile}))
+	token, err := saramaConfig.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "secret-token", token.Token)
+}
@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/archive"
+	. "kafka-topic-monitor/pkg/logger"
+)
+
+// archiveSegmentRecords is the number of records of a single archive segment.
+const archiveSegmentRecords = 100000
+
+var (
+	ErrIncompleteArchive = errors.New("records of partition are not visible up to its newest offset")
+)
+
+// ArchiveTopic dumps configs and committed records of all partitions of the topic into a new archive in dir,
+// the archive is removed if it can't be completed.
+func (c *Cluster) ArchiveTopic(ctx context.Context, topicName, dir string) (*archive.Manifest, error) {
+	partitions, err := callWithContext(ctx, func() ([]int32, error) {
+		return c.client.Partitions(topicName)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions for topic %s: %w", topicName, err)
+	}
+	var replicas []int32
+	if len(partitions) > 0 {
+		replicas, err = callWithContext(ctx, func() ([]int32, error) {
+			return c.client.Replicas(topicName, partitions[0])
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replicas of partition %d: %w", partitions[0], err)
+		}
+	}
+	entries, err := callWithContext(ctx, func() ([]sarama.ConfigEntry, error) {
+		return c.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topicName})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe configs of topic %s: %w", topicName, err)
+	}
+
+	writer, err := archive.NewWriter(dir, archive.Manifest{
+		Cluster:           c.Name,
+		Topic:             topicName,
+		ArchivedAt:        time.Now(),
+		Partitions:        int32(len(partitions)),
+		ReplicationFactor: int16(len(replicas)),
+		Configs:           topicConfigOverrides(entries),
+	}, archiveSegmentRecords)
+	if err != nil {
+		return nil, err
+	}
+	// Segments are written in partition order, partitions are cached by the client and sorted on a copy.
+	partitions = slices.Sorted(slices.Values(partitions))
+	for _, partition := range partitions {
+		if err := c.archivePartition(ctx, writer, topicName, partition); err != nil {
+			if abortErr := writer.Abort(); abortErr != nil {
+				GetLogger().Warnf("Failed to remove incomplete archive %s: %v", dir, abortErr)
+			}
+			return nil, fmt.Errorf("failed to archive topic %s: %w", topicName, err)
+		}
+	}
+	manifest, err := writer.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive topic %s: %w", topicName, err)
+	}
+	GetLogger().Infof("Archived %d records of topic %s of cluster %s to %s", manifest.Records(), topicName, c.Name, dir)
+	return manifest, nil
+}
+
+// archivePartition writes records of the partition between its oldest and newest offsets, records of aborted
+// transactions are left out. The partition fails to be archived unless all offsets up to the newest one were fetched,
+// control records and aborted records included, e.g. while a transaction is open.
+func (c *Cluster) archivePartition(ctx context.Context, writer *archive.Writer, topicName string, partition int32) error {
+	oldestOffset, err := callWithContext(ctx, func() (int64, error) {
+		return c.client.GetOffset(topicName, partition, sarama.OffsetOldest)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get oldest offset for partition %d: %w", partition, err)
+	}
+	newestOffset, err := callWithContext(ctx, func() (int64, error) {
+		return c.client.GetOffset(topicName, partition, sarama.OffsetNewest)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get newest offset for partition %d: %w", partition, err)
+	}
+
+	reached, err := fetchRecords(ctx, c.client, topicName, partition, oldestOffset, newestOffset, sarama.ReadCommitted, func(record fetchedRecord) error {
+		archived := archive.Record{
+			Partition: partition,
+			Offset:    record.offset,
+			Timestamp: record.timestamp,
+			Key:       record.key,
+			Value:     record.value,
+		}
+		for _, header := range record.headers {
+			archived.Headers = append(archived.Headers, archive.Header{Key: header.Key, Value: header.Value})
+		}
+		return writer.Write(archived)
+	})
+	if err != nil {
+		return err
+	}
+	if reached < newestOffset {
+		return fmt.Errorf("%w: partition %d fetched up to offset %d of %d", ErrIncompleteArchive, partition, reached, newestOffset)
+	}
+	return nil
+}
+
+// topicConfigOverrides returns configs set on the topic itself, leaving out defaults of brokers and sensitive configs
+// whose values are not returned by brokers.
+func topicConfigOverrides(entries []sarama.ConfigEntry) map[string]string {
+	configs := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Sensitive {
+			continue
+		}
+		// Brokers before 1.1 don't report the source of configs, only whether they are defaults.
+		if entry.Source == sarama.SourceTopic || entry.Source == sarama.SourceUnknown && !entry.Default {
+			configs[entry.Name] = entry.Value
+		}
+	}
+	return configs
+}
@@ -0,0 +1,21 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicConfigOverrides(t *testing.T) {
+	configs := topicConfigOverrides([]sarama.ConfigEntry{
+		{Name: "cleanup.policy", Value: "compact", Source: sarama.SourceTopic},
+		{Name: "retention.ms", Value: "604800000", Source: sarama.SourceDefault, Default: true},
+		{Name: "min.insync.replicas", Value: "2", Source: sarama.SourceStaticBroker},
+		{Name: "sasl.jaas.config", Source: sarama.SourceTopic, Sensitive: true},
+		// Brokers before 1.1 only report defaults.
+		{Name: "max.message.bytes", Value: "2097152"},
+		{Name: "segment.ms", Value: "604800000", Default: true},
+	})
+	assert.Equal(t, map[string]string{"cleanup.policy": "compact", "max.message.bytes": "2097152"}, configs)
+}
@@ -4,19 +4,53 @@ import (
 	"context"
 	"fmt"
 	"kafka-topic-monitor/pkg/monitor/report"
+	"sort"
 	"time"
 
 	"github.com/IBM/sarama"
 )
 
-type KafkaTopicChecker struct{}
+type KafkaTopicChecker struct {
+	lastReadStrategy LastReadStrategy
+	brokerLimiter    *brokerLimiter
+	partitionTimeout time.Duration
+	lastRecordWindow int64
+	// partitionsActivity collects offsets and last write times of partitions, checkers finding last writes
+	// differently replace getPartitionsActivity.
+	partitionsActivity func(ctx context.Context, kafkaClient sarama.Client, topicName string, partitions []int32) ([]report.PartitionActivityInfo, error)
+}
 
 var (
 	_ TopicChecker = &KafkaTopicChecker{}
+	_ scanTracker  = &KafkaTopicChecker{}
+	_ scanTracker  = &OffsetMovementLastReadStrategy{}
 )
 
-func NewTopicChecker() TopicChecker {
-	return &KafkaTopicChecker{}
+// NewTopicChecker creates a checker that keeps at most maxInFlightPerBroker partition requests
+// in flight to a single broker, zero means no limit.
+// Requests of a single partition give up after partitionTimeout, non-positive timeout means no deadline besides
+// the one of the check context.
+// The last record of a partition is searched for backwards in windows of lastRecordWindow offsets.
+func NewTopicChecker(lastReadStrategy LastReadStrategy, maxInFlightPerBroker int, partitionTimeout time.Duration, lastRecordWindow int64) TopicChecker {
+	return newKafkaTopicChecker(lastReadStrategy, maxInFlightPerBroker, partitionTimeout, lastRecordWindow)
+}
+
+func newKafkaTopicChecker(lastReadStrategy LastReadStrategy, maxInFlightPerBroker int, partitionTimeout time.Duration, lastRecordWindow int64) *KafkaTopicChecker {
+	checker := &KafkaTopicChecker{
+		lastReadStrategy: lastReadStrategy,
+		brokerLimiter:    newBrokerLimiter(maxInFlightPerBroker),
+		partitionTimeout: partitionTimeout,
+		lastRecordWindow: lastRecordWindow,
+	}
+	checker.partitionsActivity = checker.getPartitionsActivity
+	return checker
+}
+
+// endScan passes the end of the scan to the last read strategy if it keeps state between scans.
+func (c *KafkaTopicChecker) endScan(failed map[string]bool) {
+	if tracker, ok := c.lastReadStrategy.(scanTracker); ok {
+		tracker.endScan(failed)
+	}
 }
 
 // CheckTopic examines a Kafka topic to determine when and where the last write and read operations occurred
@@ -24,107 +58,250 @@ func NewTopicChecker() TopicChecker {
 // - ctx: Context for timeout/cancellation
 // - topicName: The name of the Kafka topic to check
 // - kafkaClient: A sarama Kafka client
-// - kafkaAdminClient: A sarama Kafka admin client
-func (c *KafkaTopicChecker) CheckTopic(ctx context.Context, topicName string, kafkaClient sarama.Client, kafkaAdminClient sarama.ClusterAdmin) (*report.TopicActivityInfo, error) {
+// - groupOffsets: Offsets committed by consumer groups of the cluster, fetched once per scan
+func (c *KafkaTopicChecker) CheckTopic(ctx context.Context, topicName string, kafkaClient sarama.Client, groupOffsets GroupOffsets) (*report.TopicActivityInfo, error) {
 	topicActivityInfo := &report.TopicActivityInfo{}
 	// Get topic partitions
-	partitions, err := kafkaClient.Partitions(topicName)
+	partitions, err := callWithContext(ctx, func() ([]int32, error) {
+		return kafkaClient.Partitions(topicName)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get partitions for topic %s: %w", topicName, err)
 	}
+	topicActivityInfo.PartitionNumber = len(partitions)
 
-	topicActivityInfo.LastWriteTime, err = getLastWrite(kafkaClient, topicName, partitions)
+	topicActivityInfo.Partitions, err = c.partitionsActivity(ctx, kafkaClient, topicName, partitions)
 	if err != nil {
-		return nil, fmt.Errorf("error getting last read of topic %s: %v", topicName, err)
+		return nil, fmt.Errorf("error getting last write of topic %s: %w", topicName, err)
 	}
+	topicActivityInfo.LastWriteTime = getLastWrite(topicActivityInfo.Partitions)
 
-	topicActivityInfo.LastReadTime, err = getLastRead(kafkaAdminClient, topicName, partitions)
+	topicActivityInfo.LastReadTime, err = c.getLastRead(ctx, kafkaClient, topicName, topicActivityInfo.Partitions, groupOffsets[topicName])
 	if err != nil {
-		return nil, fmt.Errorf("error getting last read of topic %s: %v", topicName, err)
+		return nil, fmt.Errorf("error getting last read of topic %s: %w", topicName, err)
 	}
+	topicActivityInfo.ConsumerGroups = getConsumerGroups(topicActivityInfo.Partitions)
 
 	return topicActivityInfo, nil
 }
 
-func getLastWrite(kafkaClient sarama.Client, topicName string, partitions []int32) (time.Time, error) {
-	var lastWriteTime time.Time
+// getPartitionsActivity collects offsets and the timestamp of the last written message for each partition.
+func (c *KafkaTopicChecker) getPartitionsActivity(ctx context.Context, kafkaClient sarama.Client, topicName string, partitions []int32) ([]report.PartitionActivityInfo, error) {
+	partitionInfos := make([]report.PartitionActivityInfo, 0, len(partitions))
 
-	// Find the latest message offsets (last write) among partitions.
 	for _, partition := range partitions {
-		oldestOffset, err := kafkaClient.GetOffset(topicName, partition, sarama.OffsetOldest)
-		newestOffset, err := kafkaClient.GetOffset(topicName, partition, sarama.OffsetNewest)
-
-		// Check if there are any messages
-		if newestOffset <= oldestOffset {
-			return time.Time{}, fmt.Errorf("no messages in partition %d", partition)
-		}
-
-		// Create a consumer
-		consumer, err := sarama.NewConsumerFromClient(kafkaClient)
+		partitionInfo, err := c.getPartitionActivity(ctx, kafkaClient, topicName, partition)
 		if err != nil {
-			return time.Time{}, fmt.Errorf("failed to create consumer for partition %d: %w", partition, err)
+			return nil, err
 		}
-		defer consumer.Close()
+		partitionInfos = append(partitionInfos, partitionInfo)
+	}
+	return partitionInfos, nil
+}
 
-		// Create a partition consumer
-		partitionConsumer, err := consumer.ConsumePartition(topicName, partition, sarama.OffsetNewest-1)
-		if err != nil {
-			return time.Time{}, fmt.Errorf("failed to consume from partition %d: %w", partition, err)
-		}
-		defer partitionConsumer.Close()
+// getPartitionActivity collects offsets and the timestamp of the last written message of a partition
+// holding a request slot of the partition leader.
+func (c *KafkaTopicChecker) getPartitionActivity(ctx context.Context, kafkaClient sarama.Client, topicName string, partition int32) (report.PartitionActivityInfo, error) {
+	ctx, cancel := withTimeout(ctx, c.partitionTimeout)
+	defer cancel()
 
-		// Get the first message
-		message := <-partitionConsumer.Messages()
-		if message.Timestamp.Unix() > lastWriteTime.Unix() {
-			lastWriteTime = message.Timestamp
-		}
+	release, err := c.acquireLeader(ctx, kafkaClient, topicName, partition)
+	if err != nil {
+		return report.PartitionActivityInfo{}, err
 	}
-	return lastWriteTime, nil
+	defer release()
+
+	oldestOffset, err := callWithContext(ctx, func() (int64, error) {
+		return kafkaClient.GetOffset(topicName, partition, sarama.OffsetOldest)
+	})
+	if err != nil {
+		return report.PartitionActivityInfo{}, fmt.Errorf("failed to get oldest offset for partition %d: %w", partition, err)
+	}
+	newestOffset, err := callWithContext(ctx, func() (int64, error) {
+		return kafkaClient.GetOffset(topicName, partition, sarama.OffsetNewest)
+	})
+	if err != nil {
+		return report.PartitionActivityInfo{}, fmt.Errorf("failed to get newest offset for partition %d: %w", partition, err)
+	}
+
+	// Empty partitions were never written or retention deleted all their messages, there is no record to take the write time from.
+	if newestOffset <= oldestOffset {
+		return report.PartitionActivityInfo{
+			Partition:        partition,
+			OldestOffset:     oldestOffset,
+			NewestOffset:     newestOffset,
+			Empty:            true,
+			CommittedOffsets: make(map[string]int64),
+		}, nil
+	}
+
+	partitionInfo := report.PartitionActivityInfo{
+		Partition:        partition,
+		OldestOffset:     oldestOffset,
+		NewestOffset:     newestOffset,
+		MessageCount:     newestOffset - oldestOffset,
+		CommittedOffsets: make(map[string]int64),
+	}
+	if err := c.findLastWrite(ctx, kafkaClient, topicName, &partitionInfo); err != nil {
+		return report.PartitionActivityInfo{}, err
+	}
+	return partitionInfo, nil
 }
 
-func getLastRead(kafkaAdminClient sarama.ClusterAdmin, topicName string, partitions []int32) (time.Time, error) {
-	listGroupsResponse, err := kafkaAdminClient.ListConsumerGroups()
+// findLastWrite fills the last write time of a partition holding messages from its last data record, the partition
+// is empty if it holds control records or records of aborted transactions only.
+func (c *KafkaTopicChecker) findLastWrite(ctx context.Context, kafkaClient sarama.Client, topicName string, partitionInfo *report.PartitionActivityInfo) error {
+	record, found, err := findLastRecord(ctx, kafkaClient, topicName, partitionInfo.Partition, partitionInfo.OldestOffset, partitionInfo.NewestOffset, c.lastRecordWindow)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to list consumer groups: %w", err)
+		return err
 	}
+	if !found {
+		partitionInfo.Empty = true
+		partitionInfo.SearchDistance = partitionInfo.NewestOffset - partitionInfo.OldestOffset
+		return nil
+	}
+	partitionInfo.LastWriteTime = record.timestamp
+	partitionInfo.SearchDistance = partitionInfo.NewestOffset - record.offset
+	return nil
+}
 
-	var lastReadTime time.Time
-	// Get consumer group offsets for each group
-	for groupID := range listGroupsResponse {
-		// Get consumer group offsets for our topic and partitions
-		listRequest := make(map[string][]int32)
-		listRequest[topicName] = partitions
+// acquireLeader waits for a free request slot of the partition leader, the returned func releases the slot.
+func (c *KafkaTopicChecker) acquireLeader(ctx context.Context, kafkaClient sarama.Client, topicName string, partition int32) (func(), error) {
+	leader, err := callWithContext(ctx, func() (*sarama.Broker, error) {
+		return kafkaClient.Leader(topicName, partition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leader of partition %d: %w", partition, err)
+	}
+	return c.brokerLimiter.acquire(ctx, leader.Addr())
+}
 
-		offsetResponse, err := kafkaAdminClient.ListConsumerGroupOffsets(groupID, listRequest)
-		if err != nil {
-			return time.Time{}, fmt.Errorf("failed to list offsets for group %s: %w", groupID, err)
+// withTimeout derives a context with timeout, non-positive timeout keeps deadline of ctx.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// callWithContext returns result of call or the error of ctx if it is done first. sarama calls don't take a context,
+// an abandoned call keeps running in background until it is bounded by timeouts of the client.
+func callWithContext[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// getLastWrite returns the latest write time among partitions.
+func getLastWrite(partitionInfos []report.PartitionActivityInfo) time.Time {
+	var lastWriteTime time.Time
+	for _, partitionInfo := range partitionInfos {
+		if partitionInfo.LastWriteTime.After(lastWriteTime) {
+			lastWriteTime = partitionInfo.LastWriteTime
 		}
+	}
+	return lastWriteTime
+}
+
+// getLastRead records offsets committed by consumer groups of the topic into partitionInfos and
+// returns the latest read time derived by the last read strategy.
+func (c *KafkaTopicChecker) getLastRead(ctx context.Context, kafkaClient sarama.Client, topicName string, partitionInfos []report.PartitionActivityInfo, groupOffsets map[string]map[int32]*sarama.OffsetFetchResponseBlock) (time.Time, error) {
+	partitionIndex := make(map[int32]int, len(partitionInfos))
+	for i, partitionInfo := range partitionInfos {
+		partitionIndex[partitionInfo.Partition] = i
+	}
+
+	var lastReadTime time.Time
+	for groupID, topicBlocks := range groupOffsets {
+		for partition, block := range topicBlocks {
+			// Skip if there's no committed offset
+			if block.Offset < 0 {
+				continue
+			}
 
-		if topicBlocks, exists := offsetResponse.Blocks[topicName]; exists {
-			for _, block := range topicBlocks {
-				// Skip if there's no committed offset
-				if block.Offset < 0 {
-					continue
-				}
-
-				offsetMeta := block.Metadata
-
-				// Check if we have the Sarama offset manager metadata
-				// In Sarama's default implementation, the metadata contains timestamp info
-				if offsetMeta != "" {
-					parsed, err := parseOffsetMetadata(offsetMeta)
-					if err == nil && !parsed.IsZero() {
-						if parsed.Unix() > lastReadTime.Unix() {
-							lastReadTime = parsed
-						}
-					}
-				}
+			i, ok := partitionIndex[partition]
+			if !ok {
+				continue
+			}
+			partitionInfos[i].CommittedOffsets[groupID] = block.Offset
+
+			readTime, err := c.getPartitionLastRead(ctx, kafkaClient, topicName, groupID, partitionInfos[i], block)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("failed to get last read time of group %s partition %d: %w", groupID, partition, err)
+			}
+			if readTime.After(lastReadTime) {
+				lastReadTime = readTime
 			}
 		}
 	}
 	return lastReadTime, nil
 }
 
+// getPartitionLastRead derives the last read time of a group holding a request slot of the partition leader.
+func (c *KafkaTopicChecker) getPartitionLastRead(ctx context.Context, kafkaClient sarama.Client, topicName, groupID string, partitionInfo report.PartitionActivityInfo, block *sarama.OffsetFetchResponseBlock) (time.Time, error) {
+	ctx, cancel := withTimeout(ctx, c.partitionTimeout)
+	defer cancel()
+
+	release, err := c.acquireLeader(ctx, kafkaClient, topicName, partitionInfo.Partition)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer release()
+
+	return c.lastReadStrategy.LastReadTime(ctx, kafkaClient, topicName, groupID, partitionInfo, block)
+}
+
+// getConsumerGroups computes per-group, per-partition lag from the committed offsets of partitions.
+// Groups are sorted by id and partitions keep the order of partitionInfos.
+func getConsumerGroups(partitionInfos []report.PartitionActivityInfo) []report.ConsumerGroupInfo {
+	groupIndex := make(map[string]int)
+	var consumerGroups []report.ConsumerGroupInfo
+
+	for _, partitionInfo := range partitionInfos {
+		for groupID, committedOffset := range partitionInfo.CommittedOffsets {
+			i, ok := groupIndex[groupID]
+			if !ok {
+				i = len(consumerGroups)
+				groupIndex[groupID] = i
+				consumerGroups = append(consumerGroups, report.ConsumerGroupInfo{GroupID: groupID})
+			}
+
+			lag := report.Lag(partitionInfo.NewestOffset, committedOffset)
+			consumerGroups[i].TotalLag += lag
+			consumerGroups[i].Partitions = append(consumerGroups[i].Partitions, report.PartitionLagInfo{
+				Partition:       partitionInfo.Partition,
+				CommittedOffset: committedOffset,
+				NewestOffset:    partitionInfo.NewestOffset,
+				Lag:             lag,
+			})
+		}
+	}
+
+	sort.Slice(consumerGroups, func(i, j int) bool {
+		return consumerGroups[i].GroupID < consumerGroups[j].GroupID
+	})
+	return consumerGroups
+}
+
 // parseOffsetMetadata attempts to extract timestamp from metadata string
 // This is client-specific and depends on how offsets were committed.
 func parseOffsetMetadata(metadata string) (time.Time, error) {
@@ -1,8 +1,13 @@
 package monitor
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
 )
 
 func TestParseOffsetMetadata(t *testing.T) {
@@ -88,3 +93,74 @@ func TestParseOffsetMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConsumerGroups(t *testing.T) {
+	partitionInfos := []report.PartitionActivityInfo{
+		{
+			Partition:        0,
+			NewestOffset:     100,
+			CommittedOffsets: map[string]int64{"group-b": 40, "group-a": 100},
+		},
+		{
+			Partition:        1,
+			NewestOffset:     50,
+			CommittedOffsets: map[string]int64{"group-b": 60},
+		},
+		{
+			Partition:        2,
+			NewestOffset:     10,
+			CommittedOffsets: map[string]int64{},
+		},
+	}
+
+	expected := []report.ConsumerGroupInfo{
+		{
+			GroupID:  "group-a",
+			TotalLag: 0,
+			Partitions: []report.PartitionLagInfo{
+				{Partition: 0, CommittedOffset: 100, NewestOffset: 100, Lag: 0},
+			},
+		},
+		{
+			GroupID:  "group-b",
+			TotalLag: 60,
+			Partitions: []report.PartitionLagInfo{
+				{Partition: 0, CommittedOffset: 40, NewestOffset: 100, Lag: 60},
+				{Partition: 1, CommittedOffset: 60, NewestOffset: 50, Lag: 0},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, getConsumerGroups(partitionInfos))
+}
+
+func TestCallWithContext(t *testing.T) {
+	got, err := callWithContext(context.Background(), func() (int64, error) {
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), got)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	blocked := make(chan struct{})
+	defer close(blocked)
+	_, err = callWithContext(ctx, func() (int64, error) {
+		<-blocked
+		return 42, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	ctx, cancel = withTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
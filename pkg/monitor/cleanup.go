@@ -0,0 +1,477 @@
+package monitor
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"kafka-topic-monitor/pkg/audit"
+	. "kafka-topic-monitor/pkg/logger"
+	"kafka-topic-monitor/pkg/monitor/report"
+	"kafka-topic-monitor/pkg/quarantine"
+)
+
+// Modes of cleanup selecting what happens to inactive topics.
+const (
+	CleanupModeDelete     = "delete"     // Topics are deleted.
+	CleanupModeQuarantine = "quarantine" // Retention of topics is lowered, so data goes away after a warning window.
+)
+
+// Actions of cleanup runs, recorded in the audit log.
+const (
+	ActionDelete           = "delete"            // Topic was deleted, or would be deleted by a dry run.
+	ActionDeleteFailed     = "delete-failed"     // Deletion of the topic failed.
+	ActionQuarantine       = "quarantine"        // Topic was quarantined, or would be quarantined by a dry run.
+	ActionQuarantineFailed = "quarantine-failed" // Quarantine of the topic failed.
+	ActionRevert           = "revert"            // Quarantine of the topic was reverted.
+	ActionRevertFailed     = "revert-failed"     // Reverting the quarantine of the topic failed.
+	ActionSkip             = "skip"              // Topic is reclaimable but was kept, see the reason.
+)
+
+var (
+	ErrCleanupDisabled          = errors.New("cleanup is disabled")
+	ErrCleanupInProgress        = errors.New("cleanup is already in progress")
+	ErrCleanupDryRunOnly        = errors.New("cleanup is configured for dry runs only")
+	ErrInvalidConfirmationToken = errors.New("invalid confirmation token")
+	ErrEmptyAllowList           = errors.New("cleanup requires an allow list")
+	ErrNonPositiveMaxDeletions  = errors.New("max deletions must be positive")
+	ErrConfirmationRequired     = errors.New("cleanup without dry run requires a confirmation token and an audit log")
+	ErrArchiveFailed            = errors.New("archiving topic failed, topic was kept")
+	ErrUnknownCleanupMode       = errors.New("unknown cleanup mode")
+	ErrQuarantineRequired       = errors.New("quarantine requires a positive retention and a quarantine store")
+	ErrQuarantineDisabled       = errors.New("quarantine is disabled")
+	ErrNotQuarantined           = errors.New("topic is not quarantined")
+	ErrStaleScan                = errors.New("latest scan is too old to clean up topics")
+)
+
+// defaultDenyPatterns match topics of Schema Registry, Kafka Connect and Kafka Streams, which hold state of their
+// applications and may look idle for long. They are never cleaned up, in addition to topics matching the deny list.
+var defaultDenyPatterns = []string{"_schemas", "connect-*", "_connect-*", "*-changelog", "*-repartition"}
+
+// AuditLog records actions taken on topics.
+type AuditLog interface {
+	Record(audit.Entry) error
+}
+
+// QuarantineStore remembers quarantined topics with their original configs.
+type QuarantineStore interface {
+	Add(quarantine.Entry) error
+	Remove(cluster, topic string) error
+	Get(cluster, topic string) (quarantine.Entry, bool)
+	List() []quarantine.Entry
+}
+
+// topicAdmin changes topics of monitored clusters on behalf of the cleaner.
+type topicAdmin interface {
+	archiveTopic(ctx context.Context, cluster, topic, dir string) error
+	deleteTopic(ctx context.Context, cluster, topic string) error
+	quarantineTopic(ctx context.Context, cluster, topic string, retention time.Duration) (map[string]string, map[string]*string, error)
+	revertTopic(ctx context.Context, cluster, topic string, original map[string]*string) error
+	checkTopic(ctx context.Context, cluster, topic string) (*report.TopicActivityInfo, error)
+	isInternalTopic(ctx context.Context, cluster, topic string) (bool, error)
+}
+
+// Cleaner deletes or quarantines idle and empty topics inactive for longer than a grace period. Only topics matching
+// the allow list and no pattern of the deny list or defaultDenyPatterns are cleaned up, internal topics never are.
+// Runs clean up at most maxDeletions topics, the ones with the largest reclaimable storage first, and record every
+// action with the snapshot of the topic in the audit log. Topics are checked again right before they are cleaned up.
+// Topics are archived before deletion if an archive directory is set.
+type Cleaner struct {
+	dryRun              bool
+	gracePeriod         time.Duration
+	allow               []topicPattern
+	deny                []topicPattern
+	defaultDeny         []topicPattern
+	maxDeletions        int
+	confirmationToken   string
+	archiveDir          string
+	mode                string
+	quarantineRetention time.Duration
+	auditLog            AuditLog
+	quarantines         QuarantineStore
+
+	// running holds a single run or revert at a time.
+	running sync.Mutex
+}
+
+// NewCleaner creates a cleaner, auditLog is optional for dry runs only. Runs only plan deletions if dryRun is set,
+// otherwise runs which are not dry runs have to present confirmationToken. Topics are archived to a new directory
+// of archiveDir before deletion unless it is empty. In quarantine mode retention of topics is lowered
+// to quarantineRetention instead of deleting them and their original configs are kept in quarantines.
+func NewCleaner(dryRun bool, gracePeriod time.Duration, allow, deny []string, maxDeletions int, confirmationToken, archiveDir, mode string, quarantineRetention time.Duration, auditLog AuditLog, quarantines QuarantineStore) (*Cleaner, error) {
+	if len(allow) == 0 {
+		return nil, ErrEmptyAllowList
+	}
+	if maxDeletions <= 0 {
+		return nil, ErrNonPositiveMaxDeletions
+	}
+	if !dryRun && (confirmationToken == "" || auditLog == nil) {
+		return nil, ErrConfirmationRequired
+	}
+	switch mode {
+	case "":
+		mode = CleanupModeDelete
+	case CleanupModeDelete:
+	case CleanupModeQuarantine:
+		if quarantineRetention <= 0 || quarantines == nil {
+			return nil, ErrQuarantineRequired
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCleanupMode, mode)
+	}
+
+	allowPatterns, err := newTopicPatterns(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyPatterns, err := newTopicPatterns(deny)
+	if err != nil {
+		return nil, err
+	}
+	defaultDeny, err := newTopicPatterns(defaultDenyPatterns)
+	if err != nil {
+		return nil, err
+	}
+	return &Cleaner{
+		dryRun:              dryRun,
+		gracePeriod:         gracePeriod,
+		allow:               allowPatterns,
+		deny:                denyPatterns,
+		defaultDeny:         defaultDeny,
+		maxDeletions:        maxDeletions,
+		confirmationToken:   confirmationToken,
+		archiveDir:          archiveDir,
+		mode:                mode,
+		quarantineRetention: quarantineRetention,
+		auditLog:            auditLog,
+		quarantines:         quarantines,
+	}, nil
+}
+
+// Run plans cleanup of topics of the scan and deletes or quarantines them with admin unless dryRun is set, topics
+// are archived before deletion if the cleaner has an archive directory and kept if archiving fails. Every topic
+// is checked again before it is cleaned up and skipped if it became active or turns out to be internal.
+// Runs which are not dry runs are refused if the cleaner is configured for dry runs only or token doesn't match
+// the confirmation token. The run stops if an action can't be recorded in the audit log.
+func (c *Cleaner) Run(ctx context.Context, scan *report.Scan, now time.Time, dryRun bool, token string, admin topicAdmin) ([]audit.Entry, error) {
+	entries, err := c.start(scan, now, dryRun, token)
+	if err != nil {
+		return nil, err
+	}
+	return c.execute(ctx, entries, now, dryRun, admin)
+}
+
+// start confirms the run, holds the cleaner for it and returns the planned actions with IDs of their audit entries.
+// The cleaner is released by execute, which has to be called with the planned actions.
+func (c *Cleaner) start(scan *report.Scan, now time.Time, dryRun bool, token string) ([]audit.Entry, error) {
+	if !dryRun {
+		if err := c.confirm(token); err != nil {
+			return nil, err
+		}
+	}
+	if !c.running.TryLock() {
+		return nil, ErrCleanupInProgress
+	}
+
+	entries := c.plan(scan, now)
+	for i := range entries {
+		id, err := newEntryID()
+		if err != nil {
+			c.running.Unlock()
+			return nil, err
+		}
+		entries[i].ID = id
+		entries[i].DryRun = dryRun
+	}
+	return entries, nil
+}
+
+// execute takes the planned actions, records them in the audit log and releases the cleaner.
+func (c *Cleaner) execute(ctx context.Context, entries []audit.Entry, now time.Time, dryRun bool, admin topicAdmin) ([]audit.Entry, error) {
+	defer c.running.Unlock()
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.Time = time.Now()
+		if !dryRun && entry.Action != ActionSkip {
+			if err := c.recheck(ctx, entry, now, admin); err != nil {
+				entry.Action = failedAction(entry.Action)
+				entry.Error = err.Error()
+				GetLogger().Errorf("failed to check topic %s of cluster %s again: %v", entry.Topic, entry.Cluster, err)
+			}
+		}
+		if !dryRun {
+			switch entry.Action {
+			case ActionDelete:
+				if err := c.delete(ctx, entry, admin); err != nil {
+					entry.Action = ActionDeleteFailed
+					entry.Error = err.Error()
+					GetLogger().Errorf("failed to delete topic %s of cluster %s: %v", entry.Topic, entry.Cluster, err)
+				} else {
+					GetLogger().Infof("Deleted topic %s of cluster %s: %s", entry.Topic, entry.Cluster, entry.Reason)
+				}
+			case ActionQuarantine:
+				if err := c.quarantine(ctx, entry, admin); err != nil {
+					entry.Action = ActionQuarantineFailed
+					entry.Error = err.Error()
+					GetLogger().Errorf("failed to quarantine topic %s of cluster %s: %v", entry.Topic, entry.Cluster, err)
+				} else {
+					GetLogger().Infof("Quarantined topic %s of cluster %s: %s", entry.Topic, entry.Cluster, entry.Reason)
+				}
+			}
+		}
+
+		if c.auditLog == nil {
+			continue
+		}
+		if err := c.auditLog.Record(*entry); err != nil {
+			return entries[:i+1], fmt.Errorf("failed to audit %s of topic %s of cluster %s: %w", entry.Action, entry.Topic, entry.Cluster, err)
+		}
+	}
+	return entries, nil
+}
+
+// Quarantined returns quarantined topics.
+func (c *Cleaner) Quarantined() ([]quarantine.Entry, error) {
+	if c.quarantines == nil {
+		return nil, ErrQuarantineDisabled
+	}
+	return c.quarantines.List(), nil
+}
+
+// Revert restores the original configs of the quarantined topic with admin and forgets the quarantine,
+// token has to match the confirmation token. The revert is recorded in the audit log.
+func (c *Cleaner) Revert(ctx context.Context, cluster, topic, token string, admin topicAdmin) (audit.Entry, error) {
+	if c.quarantines == nil {
+		return audit.Entry{}, ErrQuarantineDisabled
+	}
+	if err := c.confirm(token); err != nil {
+		return audit.Entry{}, err
+	}
+	if !c.running.TryLock() {
+		return audit.Entry{}, ErrCleanupInProgress
+	}
+	defer c.running.Unlock()
+
+	quarantined, ok := c.quarantines.Get(cluster, topic)
+	if !ok {
+		return audit.Entry{}, fmt.Errorf("%w: %s", ErrNotQuarantined, topic)
+	}
+
+	id, err := newEntryID()
+	if err != nil {
+		return audit.Entry{}, err
+	}
+	entry := audit.Entry{
+		ID:      id,
+		Time:    time.Now(),
+		Action:  ActionRevert,
+		Cluster: cluster,
+		Topic:   topic,
+		Reason:  fmt.Sprintf("quarantined since %s", quarantined.QuarantinedAt.Format(time.RFC3339)),
+	}
+	err = admin.revertTopic(ctx, cluster, topic, quarantined.Original)
+	if err == nil {
+		err = c.quarantines.Remove(cluster, topic)
+	}
+	if err != nil {
+		entry.Action = ActionRevertFailed
+		entry.Error = err.Error()
+		GetLogger().Errorf("failed to revert quarantine of topic %s of cluster %s: %v", topic, cluster, err)
+	} else {
+		GetLogger().Infof("Reverted quarantine of topic %s of cluster %s", topic, cluster)
+	}
+
+	if auditErr := c.auditLog.Record(entry); auditErr != nil {
+		return entry, fmt.Errorf("failed to audit %s of topic %s of cluster %s: %w", entry.Action, topic, cluster, auditErr)
+	}
+	return entry, err
+}
+
+// confirm refuses changes of topics if the cleaner is configured for dry runs only or token doesn't match
+// the confirmation token.
+func (c *Cleaner) confirm(token string) error {
+	if c.dryRun {
+		return ErrCleanupDryRunOnly
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(c.confirmationToken)) != 1 {
+		return ErrInvalidConfirmationToken
+	}
+	return nil
+}
+
+// recheck checks the topic of the entry again since the scan it was planned from may be outdated, the entry is
+// turned into a skip with the fresh snapshot of the topic if the topic may not be cleaned up anymore.
+func (c *Cleaner) recheck(ctx context.Context, entry *audit.Entry, now time.Time, admin topicAdmin) error {
+	internal, err := admin.isInternalTopic(ctx, entry.Cluster, entry.Topic)
+	if err != nil {
+		return err
+	}
+	if internal {
+		entry.Action = ActionSkip
+		entry.Reason = "internal topic"
+		return nil
+	}
+
+	info, err := admin.checkTopic(ctx, entry.Cluster, entry.Topic)
+	if err != nil {
+		return err
+	}
+	snapshot := *info
+	entry.Snapshot = &snapshot
+	if !info.Status.Reclaimable() {
+		entry.Action = ActionSkip
+		entry.Reason = fmt.Sprintf("topic is %s when checked again", info.Status)
+		return nil
+	}
+	if reason := c.skipReason(info, now, entry.Action); reason != "" {
+		entry.Action = ActionSkip
+		entry.Reason = reason + " when checked again"
+	}
+	return nil
+}
+
+// newEntryID returns a random ID of an audit entry.
+func newEntryID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate audit entry ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// failedAction returns the action recording failure of the action.
+func failedAction(action string) string {
+	if action == ActionQuarantine {
+		return ActionQuarantineFailed
+	}
+	return ActionDeleteFailed
+}
+
+// delete archives the topic of the entry if the cleaner has an archive directory and deletes it.
+func (c *Cleaner) delete(ctx context.Context, entry *audit.Entry, admin topicAdmin) error {
+	if c.archiveDir != "" {
+		dir := filepath.Join(c.archiveDir, entry.Cluster, entry.Topic+"-"+entry.Time.UTC().Format("20060102T150405Z"))
+		if err := admin.archiveTopic(ctx, entry.Cluster, entry.Topic, dir); err != nil {
+			return fmt.Errorf("%w: %w", ErrArchiveFailed, err)
+		}
+		entry.Archive = dir
+	}
+	return admin.deleteTopic(ctx, entry.Cluster, entry.Topic)
+}
+
+// quarantine lowers retention of the topic of the entry and remembers its original configs, the quarantine is reverted
+// if they can't be remembered.
+func (c *Cleaner) quarantine(ctx context.Context, entry *audit.Entry, admin topicAdmin) error {
+	configs, original, err := admin.quarantineTopic(ctx, entry.Cluster, entry.Topic, c.quarantineRetention)
+	if err != nil {
+		return err
+	}
+	err = c.quarantines.Add(quarantine.Entry{
+		Cluster:       entry.Cluster,
+		Topic:         entry.Topic,
+		QuarantinedAt: entry.Time,
+		Configs:       configs,
+		Original:      original,
+	})
+	if err != nil {
+		if revertErr := admin.revertTopic(ctx, entry.Cluster, entry.Topic, original); revertErr != nil {
+			return fmt.Errorf("failed to remember quarantine: %w, failed to revert it: %w", err, revertErr)
+		}
+		return fmt.Errorf("failed to remember quarantine, reverted it: %w", err)
+	}
+	return nil
+}
+
+// plan returns actions for reclaimable topics of the scan allowed to be cleaned up, topics to clean up come first
+// with the largest reclaimable storage first. Topics already quarantined are left out in quarantine mode.
+func (c *Cleaner) plan(scan *report.Scan, now time.Time) []audit.Entry {
+	candidates := slices.Clone(scan.Topics)
+	slices.SortStableFunc(candidates, func(a, b *report.TopicActivityInfo) int {
+		return cmp.Compare(b.ReclaimableBytes, a.ReclaimableBytes)
+	})
+
+	action := ActionDelete
+	if c.mode == CleanupModeQuarantine {
+		action = ActionQuarantine
+	}
+	var cleanups, skips []audit.Entry
+	for _, info := range candidates {
+		if !info.Status.Reclaimable() || !matchAny(c.allow, info.TopicName) || strings.HasPrefix(info.TopicName, internalTopicPrefix) {
+			continue
+		}
+		if action == ActionQuarantine && c.isQuarantined(info) {
+			continue
+		}
+
+		snapshot := *info
+		entry := audit.Entry{Cluster: info.Cluster, Topic: info.TopicName, Snapshot: &snapshot}
+		switch reason := c.skipReason(info, now, action); {
+		case reason != "":
+			entry.Action = ActionSkip
+			entry.Reason = reason
+		case len(cleanups) >= c.maxDeletions:
+			entry.Action = ActionSkip
+			entry.Reason = fmt.Sprintf("max deletions of %d reached", c.maxDeletions)
+		default:
+			entry.Action = action
+			entry.Reason = fmt.Sprintf("%s since %s, %d bytes reclaimable", info.Status, inactiveSince(info).Format(time.RFC3339), info.ReclaimableBytes)
+			cleanups = append(cleanups, entry)
+			continue
+		}
+		skips = append(skips, entry)
+	}
+	return append(cleanups, skips...)
+}
+
+// skipReason returns why the reclaimable topic may not be cleaned up by the action, empty if it may.
+func (c *Cleaner) skipReason(info *report.TopicActivityInfo, now time.Time, action string) string {
+	since := inactiveSince(info)
+	switch {
+	case matchAny(c.deny, info.TopicName):
+		return "topic matches deny list"
+	case matchAny(c.defaultDeny, info.TopicName):
+		return "topic matches default deny list"
+	case now.Sub(since) < c.gracePeriod:
+		return fmt.Sprintf("inactive since %s, within grace period of %v", since.Format(time.RFC3339), c.gracePeriod)
+	case action == ActionQuarantine && !retentionApplies(info.CleanupPolicy):
+		return fmt.Sprintf("retention doesn't apply to cleanup policy %s", info.CleanupPolicy)
+	}
+	return ""
+}
+
+// isQuarantined reports if the topic is quarantined.
+func (c *Cleaner) isQuarantined(info *report.TopicActivityInfo) bool {
+	_, ok := c.quarantines.Get(info.Cluster, info.TopicName)
+	return ok
+}
+
+// retentionApplies reports if records of topics with the cleanup policy are deleted by retention, policies which
+// couldn't be described are assumed to be the default delete policy.
+func retentionApplies(cleanupPolicy string) bool {
+	return cleanupPolicy == "" || slices.Contains(strings.Split(cleanupPolicy, ","), "delete")
+}
+
+// inactiveSince returns the time since which the topic is inactive: the latest scan which found it active,
+// its latest write or read, or the first scan which found it.
+func inactiveSince(info *report.TopicActivityInfo) time.Time {
+	since := info.LastActiveTime
+	for _, t := range []time.Time{info.LastWriteTime, info.LastReadTime} {
+		if t.After(since) {
+			since = t
+		}
+	}
+	if since.IsZero() {
+		return info.FirstSeenTime
+	}
+	return since
+}
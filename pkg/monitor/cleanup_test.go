@@ -0,0 +1,408 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/audit"
+	"kafka-topic-monitor/pkg/monitor/report"
+	"kafka-topic-monitor/pkg/quarantine"
+)
+
+// memoryAuditLog keeps recorded entries in memory.
+type memoryAuditLog struct {
+	entries []audit.Entry
+	err     error
+}
+
+func (l *memoryAuditLog) Record(entry audit.Entry) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// funcTopicAdmin changes topics with its funcs, topics are checked again by looking them up in scan
+// unless check is set and are not internal unless internal is set.
+type funcTopicAdmin struct {
+	archive    func(ctx context.Context, cluster, topic, dir string) error
+	delete     func(ctx context.Context, cluster, topic string) error
+	quarantine func(ctx context.Context, cluster, topic string, retention time.Duration) (map[string]string, map[string]*string, error)
+	revert     func(ctx context.Context, cluster, topic string, original map[string]*string) error
+	check      func(ctx context.Context, cluster, topic string) (*report.TopicActivityInfo, error)
+	internal   func(ctx context.Context, cluster, topic string) (bool, error)
+	scan       *report.Scan
+}
+
+func (a *funcTopicAdmin) archiveTopic(ctx context.Context, cluster, topic, dir string) error {
+	return a.archive(ctx, cluster, topic, dir)
+}
+
+func (a *funcTopicAdmin) deleteTopic(ctx context.Context, cluster, topic string) error {
+	return a.delete(ctx, cluster, topic)
+}
+
+func (a *funcTopicAdmin) quarantineTopic(ctx context.Context, cluster, topic string, retention time.Duration) (map[string]string, map[string]*string, error) {
+	return a.quarantine(ctx, cluster, topic, retention)
+}
+
+func (a *funcTopicAdmin) revertTopic(ctx context.Context, cluster, topic string, original map[string]*string) error {
+	return a.revert(ctx, cluster, topic, original)
+}
+
+func (a *funcTopicAdmin) checkTopic(ctx context.Context, cluster, topic string) (*report.TopicActivityInfo, error) {
+	if a.check != nil {
+		return a.check(ctx, cluster, topic)
+	}
+	for _, info := range a.scan.Topics {
+		if info.Cluster == cluster && info.TopicName == topic {
+			return info, nil
+		}
+	}
+	return nil, sarama.ErrUnknownTopicOrPartition
+}
+
+func (a *funcTopicAdmin) isInternalTopic(ctx context.Context, cluster, topic string) (bool, error) {
+	if a.internal != nil {
+		return a.internal(ctx, cluster, topic)
+	}
+	return false, nil
+}
+
+func TestNewCleaner(t *testing.T) {
+	_, err := NewCleaner(true, 0, nil, nil, 1, "", "", "", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrEmptyAllowList)
+
+	_, err = NewCleaner(true, 0, []string{"*"}, nil, 0, "", "", "", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrNonPositiveMaxDeletions)
+
+	_, err = NewCleaner(false, 0, []string{"*"}, nil, 1, "", "", "", 0, &memoryAuditLog{}, nil)
+	assert.ErrorIs(t, err, ErrConfirmationRequired)
+
+	_, err = NewCleaner(false, 0, []string{"*"}, nil, 1, "secret", "", "", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrConfirmationRequired)
+
+	_, err = NewCleaner(true, 0, []string{"/[/"}, nil, 1, "", "", "", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidTopicPattern)
+
+	_, err = NewCleaner(true, 0, []string{"*"}, nil, 1, "", "", "archive", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrUnknownCleanupMode)
+
+	_, err = NewCleaner(true, 0, []string{"*"}, nil, 1, "", "", CleanupModeQuarantine, time.Hour, nil, nil)
+	assert.ErrorIs(t, err, ErrQuarantineRequired)
+}
+
+func TestCleanerRun(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	scan := &report.Scan{
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "tmp-small", Status: report.StatusIdle, LastWriteTime: now.Add(-60 * day), ReclaimableBytes: 10},
+			{Cluster: "cluster-a", TopicName: "tmp-large", Status: report.StatusEmpty, FirstSeenTime: now.Add(-90 * day), ReclaimableBytes: 1000},
+			{Cluster: "cluster-a", TopicName: "tmp-medium", Status: report.StatusIdle, LastActiveTime: now.Add(-45 * day), ReclaimableBytes: 100},
+			{Cluster: "cluster-a", TopicName: "tmp-recent", Status: report.StatusIdle, LastReadTime: now.Add(-10 * day), ReclaimableBytes: 500},
+			{Cluster: "cluster-a", TopicName: "tmp-keep", Status: report.StatusIdle, LastWriteTime: now.Add(-60 * day), ReclaimableBytes: 700},
+			{Cluster: "cluster-a", TopicName: "tmp-active", Status: report.StatusWriteOnly, LastWriteTime: now},
+			{Cluster: "cluster-a", TopicName: "tmp-failed", Status: report.StatusError, Error: "request timed out"},
+			{Cluster: "cluster-a", TopicName: "orders", Status: report.StatusIdle, LastWriteTime: now.Add(-60 * day)},
+		},
+	}
+
+	auditLog := &memoryAuditLog{}
+	cleaner, err := NewCleaner(false, 30*day, []string{"tmp-*"}, []string{"*-keep"}, 2, "secret", "", "", 0, auditLog, nil)
+	require.NoError(t, err)
+
+	var deleted []string
+	deleteTopic := func(ctx context.Context, cluster, topic string) error {
+		if topic == "tmp-medium" {
+			return errors.New("topic deletion is disabled")
+		}
+		deleted = append(deleted, cluster+"/"+topic)
+		return nil
+	}
+
+	// Dry runs don't delete anything.
+	entries, err := cleaner.Run(context.Background(), scan, now, true, "", &funcTopicAdmin{delete: deleteTopic})
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	type action struct{ topic, action string }
+	actions := func(entries []audit.Entry) []action {
+		var result []action
+		for _, entry := range entries {
+			result = append(result, action{entry.Topic, entry.Action})
+		}
+		return result
+	}
+	expected := []action{
+		{"tmp-large", ActionDelete},
+		{"tmp-medium", ActionDelete},
+		{"tmp-keep", ActionSkip},
+		{"tmp-recent", ActionSkip},
+		{"tmp-small", ActionSkip},
+	}
+	assert.Equal(t, expected, actions(entries))
+	assert.True(t, entries[0].DryRun)
+	assert.Equal(t, "tmp-large", entries[0].Snapshot.TopicName)
+	assert.Equal(t, "topic matches deny list", entries[2].Reason)
+	assert.Contains(t, entries[3].Reason, "within grace period")
+	assert.Contains(t, entries[4].Reason, "max deletions of 2 reached")
+	assert.Len(t, auditLog.entries, len(entries))
+
+	_, err = cleaner.Run(context.Background(), scan, now, false, "wrong", &funcTopicAdmin{delete: deleteTopic})
+	assert.ErrorIs(t, err, ErrInvalidConfirmationToken)
+	assert.Empty(t, deleted)
+
+	auditLog.entries = nil
+	entries, err = cleaner.Run(context.Background(), scan, now, false, "secret", &funcTopicAdmin{delete: deleteTopic, scan: scan})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cluster-a/tmp-large"}, deleted)
+	assert.Equal(t, ActionDelete, entries[0].Action)
+	assert.False(t, entries[0].DryRun)
+	assert.Equal(t, ActionDeleteFailed, entries[1].Action)
+	assert.Equal(t, "topic deletion is disabled", entries[1].Error)
+	assert.Equal(t, entries, auditLog.entries)
+
+	// The run stops at the first action which can't be audited.
+	deleted = nil
+	auditLog.err = errors.New("disk full")
+	entries, err = cleaner.Run(context.Background(), scan, now, false, "secret", &funcTopicAdmin{delete: deleteTopic, scan: scan})
+	assert.Error(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, []string{"cluster-a/tmp-large"}, deleted)
+}
+
+func TestCleanerRunDryRunOnly(t *testing.T) {
+	cleaner, err := NewCleaner(true, 0, []string{"*"}, nil, 1, "", "", "", 0, nil, nil)
+	require.NoError(t, err)
+
+	_, err = cleaner.Run(context.Background(), &report.Scan{}, time.Now(), false, "", &funcTopicAdmin{})
+	assert.ErrorIs(t, err, ErrCleanupDryRunOnly)
+}
+
+func TestCleanerRunArchive(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	scan := &report.Scan{
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "tmp-large", Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -1, 0), ReclaimableBytes: 1000},
+			{Cluster: "cluster-a", TopicName: "tmp-small", Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -1, 0), ReclaimableBytes: 10},
+		},
+	}
+
+	cleaner, err := NewCleaner(false, 0, []string{"tmp-*"}, nil, 10, "secret", "/archive", "", 0, &memoryAuditLog{}, nil)
+	require.NoError(t, err)
+
+	archived := make(map[string]string)
+	archiveTopic := func(ctx context.Context, cluster, topic, dir string) error {
+		if topic == "tmp-small" {
+			return errors.New("no space left on device")
+		}
+		archived[topic] = dir
+		return nil
+	}
+	var deleted []string
+	deleteTopic := func(ctx context.Context, cluster, topic string) error {
+		deleted = append(deleted, topic)
+		return nil
+	}
+
+	entries, err := cleaner.Run(context.Background(), scan, now, false, "secret", &funcTopicAdmin{archive: archiveTopic, delete: deleteTopic, scan: scan})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ActionDelete, entries[0].Action)
+	assert.Equal(t, archived["tmp-large"], entries[0].Archive)
+	assert.Equal(t, filepath.Join("/archive", "cluster-a"), filepath.Dir(entries[0].Archive))
+
+	// Topics which failed to be archived are kept.
+	assert.Equal(t, ActionDeleteFailed, entries[1].Action)
+	assert.Contains(t, entries[1].Error, ErrArchiveFailed.Error())
+	assert.Empty(t, entries[1].Archive)
+	assert.Equal(t, []string{"tmp-large"}, deleted)
+}
+
+func TestCleanerQuarantine(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	scan := &report.Scan{
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "tmp-large", Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -1, 0), ReclaimableBytes: 1000, CleanupPolicy: "delete"},
+			{Cluster: "cluster-a", TopicName: "tmp-compacted", Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -1, 0), ReclaimableBytes: 100, CleanupPolicy: "compact"},
+			{Cluster: "cluster-a", TopicName: "tmp-small", Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -1, 0), ReclaimableBytes: 10, CleanupPolicy: "compact,delete"},
+		},
+	}
+
+	quarantines, err := quarantine.NewFileStore(filepath.Join(t.TempDir(), "quarantine.json"))
+	require.NoError(t, err)
+	auditLog := &memoryAuditLog{}
+	cleaner, err := NewCleaner(false, 0, []string{"tmp-*"}, nil, 10, "secret", "", CleanupModeQuarantine, time.Hour, auditLog, quarantines)
+	require.NoError(t, err)
+
+	originalRetention := "604800000"
+	reverted := make(map[string]map[string]*string)
+	admin := &funcTopicAdmin{
+		quarantine: func(ctx context.Context, cluster, topic string, retention time.Duration) (map[string]string, map[string]*string, error) {
+			return map[string]string{configRetentionMs: "3600000"}, map[string]*string{configRetentionMs: &originalRetention}, nil
+		},
+		revert: func(ctx context.Context, cluster, topic string, original map[string]*string) error {
+			reverted[topic] = original
+			return nil
+		},
+		scan: scan,
+	}
+
+	entries, err := cleaner.Run(context.Background(), scan, now, false, "secret", admin)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, ActionQuarantine, entries[0].Action)
+	assert.Equal(t, "tmp-large", entries[0].Topic)
+	assert.Equal(t, ActionQuarantine, entries[1].Action)
+	assert.Equal(t, "tmp-small", entries[1].Topic)
+	assert.Equal(t, ActionSkip, entries[2].Action)
+	assert.Equal(t, "retention doesn't apply to cleanup policy compact", entries[2].Reason)
+
+	quarantined, err := cleaner.Quarantined()
+	require.NoError(t, err)
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, "tmp-large", quarantined[0].Topic)
+	assert.Equal(t, map[string]string{configRetentionMs: "3600000"}, quarantined[0].Configs)
+	assert.Equal(t, &originalRetention, quarantined[0].Original[configRetentionMs])
+
+	// Quarantined topics are not planned again.
+	entries, err = cleaner.Run(context.Background(), scan, now, true, "", admin)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "tmp-compacted", entries[0].Topic)
+
+	_, err = cleaner.Revert(context.Background(), "cluster-a", "tmp-large", "wrong", admin)
+	assert.ErrorIs(t, err, ErrInvalidConfirmationToken)
+	_, err = cleaner.Revert(context.Background(), "cluster-a", "tmp-compacted", "secret", admin)
+	assert.ErrorIs(t, err, ErrNotQuarantined)
+
+	entry, err := cleaner.Revert(context.Background(), "cluster-a", "tmp-large", "secret", admin)
+	require.NoError(t, err)
+	assert.Equal(t, ActionRevert, entry.Action)
+	assert.Equal(t, map[string]*string{configRetentionMs: &originalRetention}, reverted["tmp-large"])
+	assert.Equal(t, entry, auditLog.entries[len(auditLog.entries)-1])
+	_, ok := quarantines.Get("cluster-a", "tmp-large")
+	assert.False(t, ok)
+
+	// Failed reverts keep the quarantine.
+	admin.revert = func(ctx context.Context, cluster, topic string, original map[string]*string) error {
+		return errors.New("cluster authorization failed")
+	}
+	entry, err = cleaner.Revert(context.Background(), "cluster-a", "tmp-small", "secret", admin)
+	assert.Error(t, err)
+	assert.Equal(t, ActionRevertFailed, entry.Action)
+	_, ok = quarantines.Get("cluster-a", "tmp-small")
+	assert.True(t, ok)
+}
+
+func TestCleanerRunChecksTopicsAgain(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	idle := func(topic string) *report.TopicActivityInfo {
+		return &report.TopicActivityInfo{Cluster: "cluster-a", TopicName: topic, Status: report.StatusIdle, LastWriteTime: now.AddDate(0, -2, 0), ReclaimableBytes: 10}
+	}
+	scan := &report.Scan{
+		Topics: []*report.TopicActivityInfo{
+			idle("tmp-idle"), idle("tmp-written"), idle("tmp-internal"), idle("tmp-deleted"),
+			idle("connect-offsets"), idle("app-store-changelog"), idle("_schemas"),
+		},
+	}
+
+	cleaner, err := NewCleaner(false, 30*24*time.Hour, []string{"*"}, nil, 10, "secret", "", "", 0, &memoryAuditLog{}, nil)
+	require.NoError(t, err)
+
+	var deleted []string
+	admin := &funcTopicAdmin{
+		delete: func(ctx context.Context, cluster, topic string) error {
+			deleted = append(deleted, topic)
+			return nil
+		},
+		check: func(ctx context.Context, cluster, topic string) (*report.TopicActivityInfo, error) {
+			switch topic {
+			case "tmp-written":
+				info := idle(topic)
+				info.Status, info.LastWriteTime = report.StatusWriteOnly, now
+				return info, nil
+			case "tmp-deleted":
+				return nil, sarama.ErrUnknownTopicOrPartition
+			}
+			return idle(topic), nil
+		},
+		internal: func(ctx context.Context, cluster, topic string) (bool, error) {
+			return topic == "tmp-internal", nil
+		},
+	}
+
+	entries, err := cleaner.Run(context.Background(), scan, now, false, "secret", admin)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tmp-idle"}, deleted)
+
+	byTopic := make(map[string]audit.Entry)
+	for _, entry := range entries {
+		byTopic[entry.Topic] = entry
+	}
+	assert.Equal(t, ActionDelete, byTopic["tmp-idle"].Action)
+	assert.Equal(t, ActionSkip, byTopic["tmp-written"].Action)
+	assert.Equal(t, "topic is write-only when checked again", byTopic["tmp-written"].Reason)
+	assert.Equal(t, report.StatusWriteOnly, byTopic["tmp-written"].Snapshot.Status)
+	assert.Equal(t, ActionSkip, byTopic["tmp-internal"].Action)
+	assert.Equal(t, "internal topic", byTopic["tmp-internal"].Reason)
+	assert.Equal(t, ActionDeleteFailed, byTopic["tmp-deleted"].Action)
+	for _, topic := range []string{"connect-offsets", "app-store-changelog", "_schemas"} {
+		assert.Equal(t, ActionSkip, byTopic[topic].Action, topic)
+		assert.Equal(t, "topic matches default deny list", byTopic[topic].Reason, topic)
+	}
+}
+
+func TestMonitorCleanupRefusesStaleScan(t *testing.T) {
+	cleaner, err := NewCleaner(false, 0, []string{"tmp-*"}, nil, 1, "secret", "", "", 0, &memoryAuditLog{}, nil)
+	require.NoError(t, err)
+	m := &Monitor{ScanInterval: time.Minute, cleaner: cleaner}
+	scan := &report.Scan{ScannedAt: time.Now().Add(-time.Hour), Duration: time.Minute}
+
+	response := m.cleanup(context.Background(), scan, cleanupQuery{token: "secret"})
+	assert.ErrorIs(t, response.err, ErrStaleScan)
+
+	// Dry runs only plan cleanup, they may use an old scan.
+	response = m.cleanup(context.Background(), scan, cleanupQuery{dryRun: true})
+	assert.NoError(t, response.err)
+	assert.False(t, response.accepted)
+}
+
+func TestMonitorCleanupInBackground(t *testing.T) {
+	auditLog := &memoryAuditLog{}
+	cleaner, err := NewCleaner(false, 30*24*time.Hour, []string{"tmp-*"}, nil, 1, "secret", "", "", 0, auditLog, nil)
+	require.NoError(t, err)
+	m := &Monitor{ScanInterval: time.Minute, cleaner: cleaner}
+	scan := &report.Scan{
+		ScannedAt: time.Now(),
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "tmp-recent", Status: report.StatusIdle, LastWriteTime: time.Now().Add(-24 * time.Hour)},
+		},
+	}
+
+	// Runs which are not dry runs are accepted with the planned actions and the IDs of their audit entries.
+	response := m.cleanup(context.Background(), scan, cleanupQuery{token: "secret"})
+	require.NoError(t, response.err)
+	assert.True(t, response.accepted)
+	var planned []audit.Entry
+	require.NoError(t, json.Unmarshal(response.report, &planned))
+	require.Len(t, planned, 1)
+	assert.NotEmpty(t, planned[0].ID)
+	assert.Equal(t, ActionSkip, planned[0].Action)
+
+	// The cleaner is released once the actions are audited.
+	require.Eventually(t, cleaner.running.TryLock, 5*time.Second, 10*time.Millisecond)
+	defer cleaner.running.Unlock()
+	require.Len(t, auditLog.entries, 1)
+	assert.Equal(t, planned[0].ID, auditLog.entries[0].ID)
+	assert.False(t, auditLog.entries[0].DryRun)
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	. "kafka-topic-monitor/pkg/logger"
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// Cluster is a Kafka cluster scanned by the monitor
+type Cluster struct {
+	Name             string
+	BootstrapServers []string
+	InactivityDays   int
+	StorageCostPerGB float64
+
+	client       sarama.Client
+	admin        sarama.ClusterAdmin
+	checker      TopicChecker
+	checkTimeout time.Duration
+	// incrementalAlterConfigs tells if brokers support IncrementalAlterConfigs, added in Kafka 2.3.
+	incrementalAlterConfigs bool
+}
+
+// NewCluster connects to a Kafka cluster checked by checker
+// config carries connection settings such as Kafka version, TLS and SASL, monitor specific consumer settings are set on top of it.
+// Checkers keep state between scans, e.g. offsets seen by the last read strategy, so every cluster needs its own checker.
+// Storage cost of topics is reported as their size in GB times storageCostPerGB.
+// A check of a topic, including describing it, gives up after checkTimeout, non-positive timeout means no deadline
+// besides the one of the scan context.
+func NewCluster(name string, servers []string, config *sarama.Config, inactivityDays int, storageCostPerGB float64, checker TopicChecker, checkTimeout time.Duration) (*Cluster, error) {
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = false
+
+	client, err := sarama.NewClient(servers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client of cluster %s: %w", name, err)
+	}
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka cluster admin of cluster %s: %w", name, err)
+	}
+
+	return &Cluster{
+		Name:             name,
+		BootstrapServers: servers,
+		InactivityDays:   inactivityDays,
+		StorageCostPerGB: storageCostPerGB,
+		client:           client,
+		admin:            admin,
+		checker:          checker,
+		checkTimeout:     checkTimeout,
+
+		incrementalAlterConfigs: config.Version.IsAtLeast(sarama.V2_3_0_0),
+	}, nil
+}
+
+// CheckTopic checks activity of the topic and describes its settings within the check timeout.
+// Failing to describe the topic doesn't fail the check, settings are left empty.
+func (c *Cluster) CheckTopic(ctx context.Context, topicName string, groupOffsets GroupOffsets) (*report.TopicActivityInfo, error) {
+	ctx, cancel := withTimeout(ctx, c.checkTimeout)
+	defer cancel()
+
+	info, err := c.checker.CheckTopic(ctx, topicName, c.client, groupOffsets)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.DescribeTopic(ctx, topicName, info); err != nil {
+		GetLogger().Warnf("failed to describe topic %s of cluster %s: %v", topicName, c.Name, err)
+	}
+	return info, nil
+}
+
+// endScan lets the checker drop state of topics the completed scan didn't see, failed are topics whose check failed.
+func (c *Cluster) endScan(failed map[string]bool) {
+	if tracker, ok := c.checker.(scanTracker); ok {
+		tracker.endScan(failed)
+	}
+}
+
+// ListTopics lists the Kafka topics available in the cluster
+func (c *Cluster) ListTopics() ([]string, error) {
+	topics, err := c.client.Topics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	return topics, nil
+}
+
+// DeleteTopic deletes the topic from the cluster
+func (c *Cluster) DeleteTopic(ctx context.Context, topicName string) error {
+	_, err := callWithContext(ctx, func() (struct{}, error) {
+		return struct{}{}, c.admin.DeleteTopic(topicName)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete topic %s: %w", topicName, err)
+	}
+	return nil
+}
+
+// Close shuts down the Kafka client connection
+func (c *Cluster) Close() {
+	if err := c.client.Close(); err != nil {
+		GetLogger().Infof("Error closing Kafka client of cluster %s: %v\n", c.Name, err)
+	}
+
+	if err := c.admin.Close(); err != nil {
+		GetLogger().Infof("Error closing Kafka admin of cluster %s: %v\n", c.Name, err)
+	}
+}
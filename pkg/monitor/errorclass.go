@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// classifyError returns the class of the error of a topic check, timeouts take precedence as errors
+// of Kafka requests cut short by the context are wrapped together with the context error.
+func classifyError(err error) report.ErrorClass {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, sarama.ErrRequestTimedOut):
+		return report.ErrorClassTimeout
+	case errors.Is(err, sarama.ErrTopicAuthorizationFailed), errors.Is(err, sarama.ErrGroupAuthorizationFailed),
+		errors.Is(err, sarama.ErrClusterAuthorizationFailed), errors.Is(err, sarama.ErrSASLAuthenticationFailed):
+		return report.ErrorClassAuthorization
+	case errors.Is(err, sarama.ErrLeaderNotAvailable), errors.Is(err, sarama.ErrNotLeaderForPartition),
+		errors.Is(err, sarama.ErrReplicaNotAvailable):
+		return report.ErrorClassLeaderNotAvailable
+	case errors.Is(err, sarama.ErrUnknownTopicOrPartition):
+		return report.ErrorClassUnknownTopic
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return report.ErrorClassTimeout
+	case errors.Is(err, sarama.ErrOutOfBrokers), errors.Is(err, sarama.ErrNotConnected), errors.Is(err, sarama.ErrBrokerNotAvailable),
+		errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.As(err, &netErr):
+		return report.ErrorClassNetwork
+	default:
+		return report.ErrorClassOther
+	}
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected report.ErrorClass
+	}{
+		{
+			name:     "context deadline",
+			err:      fmt.Errorf("error getting last write of topic orders: %w", context.DeadlineExceeded),
+			expected: report.ErrorClassTimeout,
+		},
+		{
+			name:     "request timed out",
+			err:      fmt.Errorf("failed to list offsets for group billing: %w", sarama.ErrRequestTimedOut),
+			expected: report.ErrorClassTimeout,
+		},
+		{
+			name:     "topic authorization",
+			err:      fmt.Errorf("failed to get partitions for topic orders: %w", sarama.ErrTopicAuthorizationFailed),
+			expected: report.ErrorClassAuthorization,
+		},
+		{
+			name:     "group authorization",
+			err:      fmt.Errorf("failed to list consumer groups: %w", sarama.ErrGroupAuthorizationFailed),
+			expected: report.ErrorClassAuthorization,
+		},
+		{
+			name:     "leader not available",
+			err:      fmt.Errorf("failed to get leader of partition 0: %w", sarama.ErrLeaderNotAvailable),
+			expected: report.ErrorClassLeaderNotAvailable,
+		},
+		{
+			name:     "topic deleted",
+			err:      fmt.Errorf("failed to get partitions for topic orders: %w", sarama.ErrUnknownTopicOrPartition),
+			expected: report.ErrorClassUnknownTopic,
+		},
+		{
+			name:     "out of brokers",
+			err:      fmt.Errorf("failed to get oldest offset for partition 0: %w", sarama.ErrOutOfBrokers),
+			expected: report.ErrorClassNetwork,
+		},
+		{
+			name:     "dial error",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: report.ErrorClassNetwork,
+		},
+		{
+			name:     "other",
+			err:      errors.New("couldn't parse timestamp from metadata"),
+			expected: report.ErrorClassOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
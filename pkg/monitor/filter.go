@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidTopicPattern = errors.New("invalid topic pattern")
+)
+
+// internalTopicPrefix is the prefix of topics created by Kafka and its tooling, e.g. __consumer_offsets
+// and __transaction_state. Internal topics are told by name rather than by the internal flag of topic metadata,
+// which brokers set only on their own topics, so topics of tooling such as __confluent.support.metrics
+// are skipped as well and listing topics needs no metadata request. Cleanup checks the flag in addition.
+const internalTopicPrefix = "__"
+
+// topicPattern matches topic names, patterns enclosed in slashes are regular expressions, others are globs.
+type topicPattern struct {
+	glob   string
+	regexp *regexp.Regexp
+}
+
+func newTopicPattern(pattern string) (topicPattern, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return topicPattern{}, fmt.Errorf("%w %s: %v", ErrInvalidTopicPattern, pattern, err)
+		}
+		return topicPattern{regexp: re}, nil
+	}
+
+	// Match reports malformed patterns only, topic names never contain path separators.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return topicPattern{}, fmt.Errorf("%w %s: %v", ErrInvalidTopicPattern, pattern, err)
+	}
+	return topicPattern{glob: pattern}, nil
+}
+
+func (p topicPattern) match(topic string) bool {
+	if p.regexp != nil {
+		return p.regexp.MatchString(topic)
+	}
+	matched, _ := path.Match(p.glob, topic)
+	return matched
+}
+
+// newTopicPatterns compiles all patterns.
+func newTopicPatterns(patterns []string) ([]topicPattern, error) {
+	compiled := make([]topicPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := newTopicPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []topicPattern, topic string) bool {
+	for _, p := range patterns {
+		if p.match(topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicFilter selects topics to check, a topic is allowed if it matches any include pattern, or there are none,
+// and matches no exclude pattern. Topics starting with __ are skipped as internal if skipInternal is set.
+type TopicFilter struct {
+	include      []topicPattern
+	exclude      []topicPattern
+	skipInternal bool
+}
+
+// NewTopicFilter compiles include and exclude patterns, patterns enclosed in slashes are regular expressions,
+// e.g. /^orders\.v[0-9]+$/, others are globs, e.g. orders-*.
+func NewTopicFilter(include, exclude []string, skipInternal bool) (*TopicFilter, error) {
+	includePatterns, err := newTopicPatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns, err := newTopicPatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TopicFilter{
+		include:      includePatterns,
+		exclude:      excludePatterns,
+		skipInternal: skipInternal,
+	}, nil
+}
+
+// Allow tells if the topic passes the filter, nil filter allows all topics.
+func (f *TopicFilter) Allow(topic string) bool {
+	if f == nil {
+		return true
+	}
+	if f.skipInternal && strings.HasPrefix(topic, internalTopicPrefix) {
+		return false
+	}
+	if len(f.include) > 0 && !matchAny(f.include, topic) {
+		return false
+	}
+	return !matchAny(f.exclude, topic)
+}
+
+// Filter returns allowed topics.
+func (f *TopicFilter) Filter(topics []string) []string {
+	allowed := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if f.Allow(topic) {
+			allowed = append(allowed, topic)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicFilter(t *testing.T) {
+	topics := []string{"__consumer_offsets", "__transaction_state", "orders", "orders-eu", "payments.v1", "payments.v2", "payments.dlq"}
+
+	tests := []struct {
+		name         string
+		include      []string
+		exclude      []string
+		skipInternal bool
+		expected     []string
+	}{
+		{
+			name:     "no patterns",
+			expected: topics,
+		},
+		{
+			name:         "skip internal topics",
+			skipInternal: true,
+			expected:     []string{"orders", "orders-eu", "payments.v1", "payments.v2", "payments.dlq"},
+		},
+		{
+			name:     "include glob",
+			include:  []string{"orders*"},
+			expected: []string{"orders", "orders-eu"},
+		},
+		{
+			name:     "include regex",
+			include:  []string{`/^payments\.v[0-9]+$/`},
+			expected: []string{"payments.v1", "payments.v2"},
+		},
+		{
+			name:         "exclude wins over include",
+			include:      []string{"payments.*", "orders"},
+			exclude:      []string{"*.dlq", "/v2$/"},
+			skipInternal: true,
+			expected:     []string{"orders", "payments.v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewTopicFilter(tt.include, tt.exclude, tt.skipInternal)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter.Filter(topics))
+		})
+	}
+}
+
+func TestTopicFilterInvalidPattern(t *testing.T) {
+	_, err := NewTopicFilter([]string{"orders-["}, nil, false)
+	assert.ErrorIs(t, err, ErrInvalidTopicPattern)
+
+	_, err = NewTopicFilter(nil, []string{"/orders-(/"}, false)
+	assert.ErrorIs(t, err, ErrInvalidTopicPattern)
+}
+
+func TestNilTopicFilterAllowsAll(t *testing.T) {
+	var filter *TopicFilter
+	assert.True(t, filter.Allow("__consumer_offsets"))
+}
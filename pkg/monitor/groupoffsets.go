@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// GroupOffsets holds offsets committed by consumer groups by topic, group and partition, fetched once per scan
+// of a cluster and shared by checks of its topics.
+type GroupOffsets map[string]map[string]map[int32]*sarama.OffsetFetchResponseBlock
+
+// ConsumerGroupOffsets lists consumer groups of the cluster and fetches offsets they committed for the topics
+// with a single request per group. Brokers 0.10.2 and newer return offsets of all topics of a group, older ones
+// are asked for the partitions of the topics.
+func (c *Cluster) ConsumerGroupOffsets(ctx context.Context, topics []string) (GroupOffsets, error) {
+	var topicPartitions map[string][]int32
+	if !c.client.Config().Version.IsAtLeast(sarama.V0_10_2_0) {
+		topicPartitions = make(map[string][]int32, len(topics))
+		for _, topic := range topics {
+			partitions, err := callWithContext(ctx, func() ([]int32, error) {
+				return c.client.Partitions(topic)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get partitions for topic %s: %w", topic, err)
+			}
+			topicPartitions[topic] = partitions
+		}
+	}
+	return fetchGroupOffsets(ctx, c.admin, topics, topicPartitions)
+}
+
+// fetchGroupOffsets fetches offsets of topicPartitions, or of all topics if nil, committed by every consumer group
+// and keeps the ones of topics.
+func fetchGroupOffsets(ctx context.Context, admin sarama.ClusterAdmin, topics []string, topicPartitions map[string][]int32) (GroupOffsets, error) {
+	groups, err := callWithContext(ctx, admin.ListConsumerGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
+	}
+
+	offsets := make(GroupOffsets, len(topics))
+	for _, topic := range topics {
+		offsets[topic] = make(map[string]map[int32]*sarama.OffsetFetchResponseBlock)
+	}
+	for groupID := range groups {
+		response, err := callWithContext(ctx, func() (*sarama.OffsetFetchResponse, error) {
+			return admin.ListConsumerGroupOffsets(groupID, topicPartitions)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list offsets for group %s: %w", groupID, err)
+		}
+		for topic, blocks := range response.Blocks {
+			if groupOffsets, ok := offsets[topic]; ok {
+				groupOffsets[groupID] = blocks
+			}
+		}
+	}
+	return offsets, nil
+}
@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGroupAdmin holds offsets committed by consumer groups, other calls of the admin client are not implemented.
+type fakeGroupAdmin struct {
+	sarama.ClusterAdmin
+	offsets  map[string]map[string]map[int32]*sarama.OffsetFetchResponseBlock // group, topic, partition
+	requests []map[string][]int32
+}
+
+func (a *fakeGroupAdmin) ListConsumerGroups() (map[string]string, error) {
+	groups := make(map[string]string, len(a.offsets))
+	for group := range a.offsets {
+		groups[group] = "consumer"
+	}
+	return groups, nil
+}
+
+func (a *fakeGroupAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	a.requests = append(a.requests, topicPartitions)
+	return &sarama.OffsetFetchResponse{Blocks: a.offsets[group]}, nil
+}
+
+func TestFetchGroupOffsets(t *testing.T) {
+	admin := &fakeGroupAdmin{offsets: map[string]map[string]map[int32]*sarama.OffsetFetchResponseBlock{
+		"group-a": {
+			"orders":   {0: {Offset: 5}, 1: {Offset: 7}},
+			"excluded": {0: {Offset: 1}},
+		},
+		"group-b": {
+			"payments": {0: {Offset: 3}},
+		},
+	}}
+
+	offsets, err := fetchGroupOffsets(context.Background(), admin, []string{"orders", "payments", "unread"}, nil)
+	require.NoError(t, err)
+	// A single request per group serves all topics.
+	assert.Len(t, admin.requests, 2)
+	assert.Nil(t, admin.requests[0])
+
+	assert.Equal(t, int64(7), offsets["orders"]["group-a"][1].Offset)
+	assert.Equal(t, int64(3), offsets["payments"]["group-b"][0].Offset)
+	assert.NotContains(t, offsets["orders"], "group-b")
+	assert.Empty(t, offsets["unread"])
+	assert.NotContains(t, offsets, "excluded")
+}
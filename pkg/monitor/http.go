@@ -1,38 +1,325 @@
 package monitor
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/IBM/sarama"
 	"github.com/gorilla/mux"
 
 	. "kafka-topic-monitor/pkg/logger"
+	"kafka-topic-monitor/pkg/monitor/report"
 )
 
-// StartHTTPServer creates and starts an HTTP server with a /topics endpoint
-func StartHTTPServer(ctx context.Context, listenAddr string, queryChan chan chan []byte) error {
+// Orders of topics in reports selected with the sort query parameter, topics keep the order of the scan by default.
+const (
+	OrderReclaimable = "reclaimable" // Largest reclaimable storage first, then largest size.
+	OrderSize        = "size"        // Largest size first.
+)
+
+var (
+	ErrUnknownOrder = errors.New("unknown topic order")
+)
+
+// reportQuery asks the monitor for report of the latest scan made by reporter, limited to a single cluster
+// and topics allowed by filter if they are set, topics are sorted by order if it is set.
+type reportQuery struct {
+	cluster  string
+	filter   *TopicFilter
+	order    func(a, b *report.TopicActivityInfo) int
+	reporter Reporter
+	response chan reportResponse
+}
+
+// reportResponse carries report of the latest scan with summary of its topic checks or an error.
+type reportResponse struct {
+	report    []byte
+	scannedAt time.Time
+	summary   report.ScanSummary
+	err       error
+}
+
+// clustersQuery asks the monitor for monitored clusters.
+type clustersQuery struct {
+	response chan clustersResponse
+}
+
+// clustersResponse carries clusters report or an error.
+type clustersResponse struct {
+	report []byte
+	err    error
+}
+
+// groupsQuery asks the monitor for consumer groups of a single topic, cluster may be omitted if a single cluster is monitored.
+type groupsQuery struct {
+	cluster  string
+	topic    string
+	response chan groupsResponse
+}
+
+// groupsResponse carries consumer groups report of a topic or an error.
+type groupsResponse struct {
+	report []byte
+	err    error
+}
+
+// historyQuery asks the monitor for history of a single topic or for summary of all topics if topic is empty.
+// Summary is limited to a single cluster if it is set.
+type historyQuery struct {
+	cluster  string
+	topic    string
+	from     time.Time
+	to       time.Time
+	response chan historyResponse
+}
+
+// historyResponse carries history report or an error.
+type historyResponse struct {
+	report []byte
+	err    error
+}
+
+// cleanupQuery asks the monitor to clean up inactive topics of the latest scan, token confirms runs which are not dry runs.
+type cleanupQuery struct {
+	dryRun   bool
+	token    string
+	response chan cleanupResponse
+}
+
+// cleanupResponse carries actions of the cleanup run or an error, accepted actions are planned and taken in background.
+type cleanupResponse struct {
+	report   []byte
+	accepted bool
+	err      error
+}
+
+// quarantineQuery asks the monitor for quarantined topics, or to revert the quarantine of topic if it is set.
+// token confirms the revert, cluster may be omitted if a single cluster is monitored.
+type quarantineQuery struct {
+	cluster  string
+	topic    string
+	token    string
+	response chan quarantineResponse
+}
+
+// quarantineResponse carries quarantined topics, the revert of a quarantine or an error.
+type quarantineResponse struct {
+	report []byte
+	err    error
+}
+
+// StartHTTPServer creates and starts an HTTP server with /topics, /topics/{name}/groups, /topics/{name}/history,
+// /topics/{name}/quarantine, /history/summary, /clusters, /clusters/{cluster}/topics, /scan, /cleanup, /quarantine
+// and /metrics endpoints
+func StartHTTPServer(ctx context.Context, listenAddr string, reporters *ReporterRegistry, queryChan chan reportQuery, clustersChan chan clustersQuery, groupsChan chan groupsQuery, historyChan chan historyQuery, cleanupChan chan cleanupQuery, quarantineChan chan quarantineQuery, refreshChan chan struct{}) error {
 	// Create a new router
 	router := mux.NewRouter()
-	topicHandler := func(w http.ResponseWriter, r *http.Request) {
-		// Set content type
-		w.Header().Set("Content-Type", "text/csv")
+	// reportHandler responds with report of the latest scan made by the reporter picked by negotiate
+	reportHandler := func(negotiate func(r *http.Request) (string, Reporter, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			contentType, reporter, err := negotiate(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			filter, err := parseTopicFilter(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			order, err := parseTopicOrder(r.URL.Query().Get("sort"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			query := reportQuery{
+				cluster:  mux.Vars(r)["cluster"],
+				filter:   filter,
+				order:    order,
+				reporter: reporter,
+				response: make(chan reportResponse),
+			}
+			queryChan <- query
+			response := <-query.response
+
+			if response.err != nil {
+				writeError(w, response.err)
+				return
+			}
+
+			// Set content type
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Add("Vary", "Accept")
+			w.Header().Set("X-Scanned-At", response.scannedAt.Format(time.RFC3339))
+			w.Header().Set("X-Topics-Total", strconv.Itoa(response.summary.Topics))
+			w.Header().Set("X-Topics-Succeeded", strconv.Itoa(response.summary.Succeeded))
+			w.Header().Set("X-Topics-Failed", strconv.Itoa(response.summary.Failed))
+
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(response.report); err != nil {
+				GetLogger().Errorf("error writing report: %v", err)
+			}
+		}
+	}
+	topicHandler := reportHandler(func(r *http.Request) (string, Reporter, error) {
+		return reporters.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	})
+	metricsHandler := reportHandler(func(r *http.Request) (string, Reporter, error) {
+		return reporters.Reporter("prometheus")
+	})
+
+	clustersHandler := func(w http.ResponseWriter, r *http.Request) {
+		query := clustersQuery{
+			response: make(chan clustersResponse),
+		}
+		clustersChan <- query
+		response := <-query.response
 
-		query := make(chan []byte)
-		queryChan <- query
-		report := <-query
+		if response.err != nil {
+			writeError(w, response.err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(response.report); err != nil {
+			GetLogger().Errorf("error writing clusters report: %v", err)
+		}
+	}
+
+	groupsHandler := func(w http.ResponseWriter, r *http.Request) {
+		query := groupsQuery{
+			cluster:  r.URL.Query().Get("cluster"),
+			topic:    mux.Vars(r)["name"],
+			response: make(chan groupsResponse),
+		}
+		groupsChan <- query
+		response := <-query.response
+
+		if response.err != nil {
+			writeError(w, response.err)
+			return
+		}
 
-		// Return topics as JSON
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write(report); err != nil {
-			GetLogger().Errorf("error writing report: %v", err)
+		if _, err := w.Write(response.report); err != nil {
+			GetLogger().Errorf("error writing groups report: %v", err)
 		}
 	}
 
+	historyHandler := func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		query := historyQuery{
+			cluster:  r.URL.Query().Get("cluster"),
+			topic:    mux.Vars(r)["name"],
+			from:     from,
+			to:       to,
+			response: make(chan historyResponse),
+		}
+		historyChan <- query
+		response := <-query.response
+
+		if response.err != nil {
+			writeError(w, response.err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(response.report); err != nil {
+			GetLogger().Errorf("error writing history report: %v", err)
+		}
+	}
+
+	cleanupHandler := func(w http.ResponseWriter, r *http.Request) {
+		dryRun := true
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(value); err != nil {
+				http.Error(w, fmt.Sprintf("invalid dry_run parameter: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		query := cleanupQuery{
+			dryRun:   dryRun,
+			token:    r.Header.Get("X-Confirmation-Token"),
+			response: make(chan cleanupResponse),
+		}
+		cleanupChan <- query
+		response := <-query.response
+
+		if response.err != nil {
+			writeError(w, response.err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if response.accepted {
+			w.WriteHeader(http.StatusAccepted)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		if _, err := w.Write(response.report); err != nil {
+			GetLogger().Errorf("error writing cleanup report: %v", err)
+		}
+	}
+
+	quarantineHandler := func(w http.ResponseWriter, r *http.Request) {
+		query := quarantineQuery{
+			cluster:  r.URL.Query().Get("cluster"),
+			topic:    mux.Vars(r)["name"],
+			token:    r.Header.Get("X-Confirmation-Token"),
+			response: make(chan quarantineResponse),
+		}
+		quarantineChan <- query
+		response := <-query.response
+
+		if response.err != nil {
+			writeError(w, response.err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(response.report); err != nil {
+			GetLogger().Errorf("error writing quarantine report: %v", err)
+		}
+	}
+
+	refreshHandler := func(w http.ResponseWriter, r *http.Request) {
+		// Refresh is already pending if the channel is full.
+		select {
+		case refreshChan <- struct{}{}:
+		default:
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+
 	// Register routes
 	router.HandleFunc("/topics", topicHandler).Methods("GET")
+	router.HandleFunc("/topics/{name}/groups", groupsHandler).Methods("GET")
+	router.HandleFunc("/topics/{name}/history", historyHandler).Methods("GET")
+	router.HandleFunc("/topics/{name}/quarantine", quarantineHandler).Methods("DELETE")
+	router.HandleFunc("/history/summary", historyHandler).Methods("GET")
+	router.HandleFunc("/clusters", clustersHandler).Methods("GET")
+	router.HandleFunc("/clusters/{cluster}/topics", topicHandler).Methods("GET")
+	router.HandleFunc("/scan", refreshHandler).Methods("POST")
+	router.HandleFunc("/cleanup", cleanupHandler).Methods("POST")
+	router.HandleFunc("/quarantine", quarantineHandler).Methods("GET")
+	router.HandleFunc("/metrics", metricsHandler).Methods("GET")
 
 	// Create the server
 	server := &http.Server{
@@ -71,3 +358,88 @@ func StartHTTPServer(ctx context.Context, listenAddr string, queryChan chan chan
 	}()
 	return nil
 }
+
+// writeError writes err with the status code matching it
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, sarama.ErrUnknownTopicOrPartition), errors.Is(err, ErrUnknownCluster), errors.Is(err, ErrNotQuarantined):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrClusterRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrNoScan), errors.Is(err, ErrStaleScan):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, ErrHistoryDisabled), errors.Is(err, ErrCleanupDisabled), errors.Is(err, ErrQuarantineDisabled):
+		status = http.StatusNotImplemented
+	case errors.Is(err, ErrCleanupDryRunOnly), errors.Is(err, ErrInvalidConfirmationToken):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrCleanupInProgress):
+		status = http.StatusConflict
+	case errors.Is(err, ErrUnsupportedFormat):
+		status = http.StatusNotAcceptable
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// parseTopicFilter parses include, exclude and skip_internal query parameters, include and exclude may be repeated.
+// The filter is nil if none of them is set.
+func parseTopicFilter(r *http.Request) (*TopicFilter, error) {
+	values := r.URL.Query()
+	if !values.Has("include") && !values.Has("exclude") && !values.Has("skip_internal") {
+		return nil, nil
+	}
+
+	var skipInternal bool
+	if value := values.Get("skip_internal"); value != "" {
+		var err error
+		if skipInternal, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid skip_internal parameter: %w", err)
+		}
+	}
+	return NewTopicFilter(values["include"], values["exclude"], skipInternal)
+}
+
+// parseTopicOrder returns the comparison of topics of the order, nil if order is empty.
+func parseTopicOrder(order string) (func(a, b *report.TopicActivityInfo) int, error) {
+	switch order {
+	case "":
+		return nil, nil
+	case OrderReclaimable:
+		return func(a, b *report.TopicActivityInfo) int {
+			if c := cmp.Compare(b.ReclaimableBytes, a.ReclaimableBytes); c != 0 {
+				return c
+			}
+			return cmp.Compare(b.SizeBytes, a.SizeBytes)
+		}, nil
+	case OrderSize:
+		return func(a, b *report.TopicActivityInfo) int {
+			return cmp.Compare(b.SizeBytes, a.SizeBytes)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrder, order)
+	}
+}
+
+// parseTimeRange parses RFC3339 from and to query parameters, the range is unbounded from the past
+// and ends now if they are omitted
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	var (
+		from = time.Time{}
+		to   = time.Now()
+		err  error
+	)
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from parameter: %w", err)
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to parameter: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to %s is before from %s", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	return from, to, nil
+}
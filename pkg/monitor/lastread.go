@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// Names of the supported last read strategies.
+//
+// Note that OffsetFetch responses do not carry the commit timestamp in any protocol version,
+// so the read time can only be derived from commit metadata, consumed records or offset movement.
+const (
+	LastReadStrategyMetadata        = "metadata"
+	LastReadStrategyRecordTimestamp = "record-timestamp"
+	LastReadStrategyOffsetMovement  = "offset-movement"
+)
+
+var (
+	ErrUnknownLastReadStrategy = errors.New("unknown last read strategy")
+)
+
+// LastReadStrategy derives the time a consumer group last read a partition from its committed offset.
+// Strategies reading from Kafka give up when ctx is done.
+type LastReadStrategy interface {
+	LastReadTime(ctx context.Context, kafkaClient sarama.Client, topicName, groupID string, partition report.PartitionActivityInfo, block *sarama.OffsetFetchResponseBlock) (time.Time, error)
+}
+
+// scanTracker is implemented by checkers and last read strategies keeping state of topics between scans.
+// endScan is called after every completed scan of the cluster, state the scan didn't see is dropped except for
+// topics whose check failed, which keep it until their check succeeds again.
+type scanTracker interface {
+	endScan(failed map[string]bool)
+}
+
+// NewLastReadStrategy creates the last read strategy with the given name, metadata strategy is used by default.
+// Strategies reading records search for them backwards in windows of lastRecordWindow offsets.
+func NewLastReadStrategy(name string, lastRecordWindow int64) (LastReadStrategy, error) {
+	switch name {
+	case "", LastReadStrategyMetadata:
+		return &MetadataLastReadStrategy{}, nil
+	case LastReadStrategyRecordTimestamp:
+		return NewRecordTimestampLastReadStrategy(lastRecordWindow), nil
+	case LastReadStrategyOffsetMovement:
+		return NewOffsetMovementLastReadStrategy(time.Now), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownLastReadStrategy, name)
+	}
+}
+
+// MetadataLastReadStrategy parses the read time from RFC3339 timestamp written by a client into commit metadata.
+type MetadataLastReadStrategy struct{}
+
+func (s *MetadataLastReadStrategy) LastReadTime(_ context.Context, _ sarama.Client, _, _ string, _ report.PartitionActivityInfo, block *sarama.OffsetFetchResponseBlock) (time.Time, error) {
+	if block.Metadata == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := parseOffsetMetadata(block.Metadata)
+	if err != nil {
+		// Metadata written by other clients is not an error, it just doesn't tell the read time.
+		return time.Time{}, nil
+	}
+	return parsed, nil
+}
+
+// RecordTimestampLastReadStrategy uses the timestamp of the last consumed data record, the last one before
+// committed offset, as the read time. A consumer reads a record no earlier than it was written,
+// so this is a lower bound of the actual read time. Control records of transactions and offsets removed
+// by compaction right before the committed offset are skipped like in the search for the last write.
+type RecordTimestampLastReadStrategy struct {
+	window int64
+}
+
+// NewRecordTimestampLastReadStrategy creates a strategy searching for the last consumed record backwards
+// from the committed offset in windows of window offsets.
+func NewRecordTimestampLastReadStrategy(window int64) *RecordTimestampLastReadStrategy {
+	return &RecordTimestampLastReadStrategy{window: window}
+}
+
+func (s *RecordTimestampLastReadStrategy) LastReadTime(ctx context.Context, kafkaClient sarama.Client, topicName, _ string, partition report.PartitionActivityInfo, block *sarama.OffsetFetchResponseBlock) (time.Time, error) {
+	// Records before the oldest offset were removed by retention, offsets past the end of partition were never written.
+	if block.Offset <= partition.OldestOffset || block.Offset > partition.NewestOffset {
+		return time.Time{}, nil
+	}
+
+	record, found, err := findLastRecord(ctx, kafkaClient, topicName, partition.Partition, partition.OldestOffset, block.Offset, s.window)
+	if err != nil || !found {
+		return time.Time{}, err
+	}
+	return record.timestamp, nil
+}
+
+// OffsetMovementLastReadStrategy remembers committed offsets between scans and reports the time of the scan
+// that first observed the offset moving. The read time is unknown until the offset moves after the first scan.
+// Offsets are forgotten once a scan doesn't see them.
+type OffsetMovementLastReadStrategy struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	positions map[offsetPositionKey]offsetPosition
+}
+
+type offsetPositionKey struct {
+	topic     string
+	groupID   string
+	partition int32
+}
+
+type offsetPosition struct {
+	offset  int64
+	movedAt time.Time
+	seen    bool // Seen by the current scan.
+}
+
+func NewOffsetMovementLastReadStrategy(now func() time.Time) *OffsetMovementLastReadStrategy {
+	return &OffsetMovementLastReadStrategy{
+		now:       now,
+		positions: make(map[offsetPositionKey]offsetPosition),
+	}
+}
+
+func (s *OffsetMovementLastReadStrategy) LastReadTime(_ context.Context, _ sarama.Client, topicName, groupID string, partition report.PartitionActivityInfo, block *sarama.OffsetFetchResponseBlock) (time.Time, error) {
+	key := offsetPositionKey{
+		topic:     topicName,
+		groupID:   groupID,
+		partition: partition.Partition,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	position, seen := s.positions[key]
+	if seen && position.offset != block.Offset {
+		position.movedAt = s.now()
+	}
+	position.offset = block.Offset
+	position.seen = true
+	s.positions[key] = position
+
+	return position.movedAt, nil
+}
+
+// endScan forgets offsets of partitions and consumer groups the scan didn't see, e.g. of deleted topics or groups.
+func (s *OffsetMovementLastReadStrategy) endScan(failed map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, position := range s.positions {
+		switch {
+		case position.seen:
+			position.seen = false
+			s.positions[key] = position
+		case !failed[key.topic]:
+			delete(s.positions, key)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestNewLastReadStrategy(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		expected    LastReadStrategy
+		expectError bool
+	}{
+		{name: "default", strategy: "", expected: &MetadataLastReadStrategy{}},
+		{name: "metadata", strategy: LastReadStrategyMetadata, expected: &MetadataLastReadStrategy{}},
+		{name: "record timestamp", strategy: LastReadStrategyRecordTimestamp, expected: &RecordTimestampLastReadStrategy{window: 500}},
+		{name: "unknown", strategy: "commit-timestamp", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLastReadStrategy(tt.strategy, 500)
+			if tt.expectError {
+				assert.True(t, errors.Is(err, ErrUnknownLastReadStrategy))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestMetadataLastReadStrategy(t *testing.T) {
+	strategy := &MetadataLastReadStrategy{}
+	partition := report.PartitionActivityInfo{Partition: 0}
+
+	got, err := strategy.LastReadTime(context.Background(), nil, "topic", "group", partition, &sarama.OffsetFetchResponseBlock{Offset: 1, Metadata: "2023-04-15T14:30:45Z"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 4, 15, 14, 30, 45, 0, time.UTC), got)
+
+	got, err = strategy.LastReadTime(context.Background(), nil, "topic", "group", partition, &sarama.OffsetFetchResponseBlock{Offset: 1, Metadata: "not a timestamp"})
+	assert.NoError(t, err)
+	assert.True(t, got.IsZero())
+}
+
+func TestRecordTimestampLastReadStrategyWithoutConsumedRecords(t *testing.T) {
+	strategy := NewRecordTimestampLastReadStrategy(500)
+	partition := report.PartitionActivityInfo{Partition: 0, OldestOffset: 10, NewestOffset: 20}
+
+	// Nothing was consumed before the oldest offset and offsets past the newest one were never written,
+	// Kafka is not asked for records then.
+	for _, offset := range []int64{5, 10, 21} {
+		got, err := strategy.LastReadTime(context.Background(), nil, "topic", "group", partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		assert.NoError(t, err)
+		assert.True(t, got.IsZero())
+	}
+}
+
+func TestOffsetMovementLastReadStrategy(t *testing.T) {
+	scanTime := time.Date(2023, 4, 15, 14, 0, 0, 0, time.UTC)
+	strategy := NewOffsetMovementLastReadStrategy(func() time.Time { return scanTime })
+	partition := report.PartitionActivityInfo{Partition: 0}
+
+	scan := func(groupID string, offset int64) time.Time {
+		got, err := strategy.LastReadTime(context.Background(), nil, "topic", groupID, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		assert.NoError(t, err)
+		return got
+	}
+
+	// First observation doesn't tell when offset moved.
+	assert.True(t, scan("group", 10).IsZero())
+
+	// Offset did not move.
+	scanTime = scanTime.Add(time.Minute)
+	assert.True(t, scan("group", 10).IsZero())
+
+	// Offset moved, read time is the time of the scan.
+	movedAt := scanTime.Add(time.Minute)
+	scanTime = movedAt
+	assert.Equal(t, movedAt, scan("group", 15))
+
+	// Offset stays, read time is remembered.
+	scanTime = scanTime.Add(time.Hour)
+	assert.Equal(t, movedAt, scan("group", 15))
+
+	// Other groups are tracked separately.
+	assert.True(t, scan("other-group", 15).IsZero())
+}
+
+func TestOffsetMovementLastReadStrategyForgetsUnseenOffsets(t *testing.T) {
+	scanTime := time.Date(2023, 4, 15, 14, 0, 0, 0, time.UTC)
+	strategy := NewOffsetMovementLastReadStrategy(func() time.Time { return scanTime })
+	partition := report.PartitionActivityInfo{Partition: 0}
+
+	scan := func(topic string, offset int64) time.Time {
+		got, err := strategy.LastReadTime(context.Background(), nil, topic, "group", partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		assert.NoError(t, err)
+		return got
+	}
+
+	scan("orders", 10)
+	scan("payments", 10)
+	scan("refunds", 10)
+	strategy.endScan(nil)
+
+	movedAt := scanTime.Add(time.Minute)
+	scanTime = movedAt
+	assert.Equal(t, movedAt, scan("orders", 15))
+	// The check of payments failed, refunds was deleted.
+	strategy.endScan(map[string]bool{"payments": true})
+	assert.Len(t, strategy.positions, 2)
+
+	// Offsets of topics whose check failed are kept until the check succeeds again.
+	scanTime = scanTime.Add(time.Minute)
+	assert.Equal(t, scanTime, scan("payments", 15))
+	// Seen offsets are kept.
+	assert.Equal(t, movedAt, scan("orders", 15))
+	strategy.endScan(nil)
+	assert.Len(t, strategy.positions, 2)
+
+	// A topic created again with the same name starts from scratch.
+	assert.True(t, scan("refunds", 20).IsZero())
+}
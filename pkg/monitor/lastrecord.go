@@ -0,0 +1,245 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// controlRecordAbort is the type of control records marking the end of aborted transactions.
+const controlRecordAbort = 0
+
+// lastRecord is the last data record of a partition found before an offset.
+type lastRecord struct {
+	offset    int64
+	timestamp time.Time
+}
+
+// fetchedRecord is a data record of a fetch response.
+type fetchedRecord struct {
+	offset    int64
+	timestamp time.Time
+	key       []byte
+	value     []byte
+	headers   []*sarama.RecordHeader
+}
+
+// findLastRecord walks the partition backwards from newestOffset in windows of window offsets and returns the last data
+// record, skipping control records of transactions, offsets removed by compaction and records of aborted transactions
+// when the isolation level of the client is read committed. found is false if no window down to oldestOffset
+// holds a data record.
+func findLastRecord(ctx context.Context, kafkaClient sarama.Client, topicName string, partition int32, oldestOffset, newestOffset, window int64) (record lastRecord, found bool, err error) {
+	window = max(window, 1)
+	for end := newestOffset; end > oldestOffset; end -= window {
+		start := max(oldestOffset, end-window)
+		record, found, err = fetchLastRecord(ctx, kafkaClient, topicName, partition, start, end)
+		if err != nil || found {
+			return record, found, err
+		}
+	}
+	return lastRecord{}, false, nil
+}
+
+// fetchLastRecord fetches offsets [start, end) from the partition leader and returns the last data record among them.
+func fetchLastRecord(ctx context.Context, kafkaClient sarama.Client, topicName string, partition int32, start, end int64) (lastRecord, bool, error) {
+	var (
+		record lastRecord
+		found  bool
+	)
+	_, err := fetchRecords(ctx, kafkaClient, topicName, partition, start, end, kafkaClient.Config().Consumer.IsolationLevel, func(fetched fetchedRecord) error {
+		record = lastRecord{offset: fetched.offset, timestamp: fetched.timestamp}
+		found = true
+		return nil
+	})
+	if err != nil {
+		return lastRecord{}, false, err
+	}
+	return record, found, nil
+}
+
+// fetchRecords fetches offsets [start, end) from the partition leader and passes data records among them to fn in offset
+// order, records of aborted transactions are skipped if isolation is read committed. Fetching stops at the first error of fn.
+// It returns the offset fetching reached, which is before end if offsets up to end were not visible, e.g. past the last
+// stable offset of open transactions.
+func fetchRecords(ctx context.Context, kafkaClient sarama.Client, topicName string, partition int32, start, end int64, isolation sarama.IsolationLevel, fn func(fetchedRecord) error) (int64, error) {
+	leader, err := callWithContext(ctx, func() (*sarama.Broker, error) {
+		return kafkaClient.Leader(topicName, partition)
+	})
+	if err != nil {
+		return start, fmt.Errorf("failed to get leader of partition %d: %w", partition, err)
+	}
+
+	var (
+		config    = kafkaClient.Config()
+		fetchSize = config.Consumer.Fetch.Default
+	)
+	offset := start
+	for offset < end {
+		request := &sarama.FetchRequest{
+			Version:      fetchVersion(config.Version),
+			MinBytes:     1,
+			MaxBytes:     fetchSize,
+			Isolation:    isolation,
+			SessionEpoch: -1,
+		}
+		request.AddBlock(topicName, partition, offset, fetchSize, -1)
+
+		response, err := callWithContext(ctx, func() (*sarama.FetchResponse, error) {
+			return leader.Fetch(request)
+		})
+		if err != nil {
+			return offset, fmt.Errorf("failed to fetch offset %d of partition %d: %w", offset, partition, err)
+		}
+		block := response.GetBlock(topicName, partition)
+		if block == nil {
+			return offset, fmt.Errorf("failed to fetch offset %d of partition %d: %w", offset, partition, sarama.ErrIncompleteResponse)
+		}
+		if block.Err != sarama.ErrNoError {
+			return offset, fmt.Errorf("failed to fetch offset %d of partition %d: %w", offset, partition, block.Err)
+		}
+
+		var fnErr error
+		next, partial := walkRecords(block, isolation, func(record fetchedRecord) {
+			if fnErr == nil && record.offset >= start && record.offset < end {
+				fnErr = fn(record)
+			}
+		})
+		if fnErr != nil {
+			return offset, fnErr
+		}
+		if next > offset {
+			offset = next
+			continue
+		}
+		if !partial {
+			// Nothing is visible at the offset, e.g. it is past the last stable offset of open transactions.
+			break
+		}
+		// A single batch doesn't fit into the fetch size, retry with a larger one like the consumer does.
+		if config.Consumer.Fetch.Max > 0 && fetchSize >= config.Consumer.Fetch.Max || fetchSize == math.MaxInt32 {
+			return offset, fmt.Errorf("failed to fetch offset %d of partition %d: %w", offset, partition, sarama.ErrMessageTooLarge)
+		}
+		fetchSize = int32(min(int64(fetchSize)*2, math.MaxInt32))
+		if config.Consumer.Fetch.Max > 0 {
+			fetchSize = min(fetchSize, config.Consumer.Fetch.Max)
+		}
+	}
+	return offset, nil
+}
+
+// walkRecords passes data records of the fetch response to fn in offset order, records
+// of aborted transactions are skipped if isolation is read committed. It returns the offset following the last complete
+// batch and whether the response ends with a partial batch.
+func walkRecords(block *sarama.FetchResponseBlock, isolation sarama.IsolationLevel, fn func(fetchedRecord)) (int64, bool) {
+	abortedTransactions := make([]*sarama.AbortedTransaction, len(block.AbortedTransactions))
+	copy(abortedTransactions, block.AbortedTransactions)
+	sort.Slice(abortedTransactions, func(i, j int) bool {
+		return abortedTransactions[i].FirstOffset < abortedTransactions[j].FirstOffset
+	})
+	abortedProducers := make(map[int64]struct{})
+
+	var (
+		next    int64 = -1
+		partial bool
+	)
+	for _, records := range block.RecordsSet {
+		if records.MsgSet != nil {
+			for _, messageBlock := range records.MsgSet.Messages {
+				walkMessages(messageBlock, fn)
+				next = max(next, messageBlock.Offset+1)
+			}
+			partial = partial || records.MsgSet.PartialTrailingMessage
+			continue
+		}
+
+		batch := records.RecordBatch
+		if batch == nil {
+			continue
+		}
+		if batch.PartialTrailingRecord {
+			partial = true
+			continue
+		}
+		next = max(next, batch.LastOffset()+1)
+
+		// Transactions aborted by producers are open from their first offset up to their abort marker.
+		for len(abortedTransactions) > 0 && abortedTransactions[0].FirstOffset <= batch.LastOffset() {
+			abortedProducers[abortedTransactions[0].ProducerID] = struct{}{}
+			abortedTransactions = abortedTransactions[1:]
+		}
+		if batch.Control {
+			for _, record := range batch.Records {
+				if len(record.Key) >= 4 && binary.BigEndian.Uint16(record.Key[2:4]) == controlRecordAbort {
+					delete(abortedProducers, batch.ProducerID)
+				}
+			}
+			continue
+		}
+		if _, aborted := abortedProducers[batch.ProducerID]; aborted && batch.IsTransactional && isolation == sarama.ReadCommitted {
+			continue
+		}
+
+		for _, record := range batch.Records {
+			timestamp := batch.FirstTimestamp.Add(record.TimestampDelta)
+			if batch.LogAppendTime {
+				timestamp = batch.MaxTimestamp
+			}
+			fn(fetchedRecord{
+				offset:    batch.FirstOffset + record.OffsetDelta,
+				timestamp: timestamp,
+				key:       record.Key,
+				value:     record.Value,
+				headers:   record.Headers,
+			})
+		}
+	}
+	return next, partial
+}
+
+// walkMessages passes messages of a legacy message block to fn, messages of compressed
+// blocks of message format v1 have offsets relative to the offset of the block.
+func walkMessages(messageBlock *sarama.MessageBlock, fn func(fetchedRecord)) {
+	messages := messageBlock.Messages()
+	var baseOffset int64
+	if messageBlock.Msg.Set != nil && messageBlock.Msg.Version >= 1 && len(messages) > 0 {
+		baseOffset = messageBlock.Offset - messages[len(messages)-1].Offset
+	}
+
+	for _, message := range messages {
+		timestamp := message.Msg.Timestamp
+		if messageBlock.Msg.LogAppendTime {
+			timestamp = messageBlock.Msg.Timestamp
+		}
+		fn(fetchedRecord{offset: baseOffset + message.Offset, timestamp: timestamp, key: message.Msg.Key, value: message.Msg.Value})
+	}
+}
+
+// fetchVersion returns the latest Fetch request version supported by both brokers of the version and the client
+// without fetch sessions, the same as picked by the sarama consumer.
+func fetchVersion(version sarama.KafkaVersion) int16 {
+	switch {
+	case version.IsAtLeast(sarama.V2_1_0_0):
+		return 10
+	case version.IsAtLeast(sarama.V2_0_0_0):
+		return 8
+	case version.IsAtLeast(sarama.V1_1_0_0):
+		return 7
+	case version.IsAtLeast(sarama.V1_0_0_0):
+		return 6
+	case version.IsAtLeast(sarama.V0_11_0_0):
+		return 5
+	case version.IsAtLeast(sarama.V0_10_1_0):
+		return 3
+	case version.IsAtLeast(sarama.V0_10_0_0):
+		return 2
+	case version.IsAtLeast(sarama.V0_9_0_0):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkRecords(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	block := &sarama.FetchResponseBlock{
+		AbortedTransactions: []*sarama.AbortedTransaction{{ProducerID: 2, FirstOffset: 2}},
+		RecordsSet: []*sarama.Records{
+			{RecordBatch: &sarama.RecordBatch{
+				FirstOffset:     0,
+				LastOffsetDelta: 1,
+				FirstTimestamp:  base,
+				ProducerID:      1,
+				Records: []*sarama.Record{
+					{OffsetDelta: 0, Key: []byte("key"), Value: []byte("value"), Headers: []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("1")}}},
+					{OffsetDelta: 1, TimestampDelta: time.Second},
+				},
+			}},
+			// Transaction aborted by its control record.
+			{RecordBatch: &sarama.RecordBatch{
+				FirstOffset:     2,
+				LastOffsetDelta: 1,
+				FirstTimestamp:  base.Add(time.Minute),
+				ProducerID:      2,
+				IsTransactional: true,
+				Records:         []*sarama.Record{{OffsetDelta: 0}, {OffsetDelta: 1}},
+			}},
+			{RecordBatch: &sarama.RecordBatch{
+				FirstOffset:     4,
+				ProducerID:      2,
+				IsTransactional: true,
+				Control:         true,
+				Records:         []*sarama.Record{{Key: []byte{0, 0, 0, 0}}},
+			}},
+			// Committed transaction with timestamps set by the broker.
+			{RecordBatch: &sarama.RecordBatch{
+				FirstOffset:     5,
+				FirstTimestamp:  base,
+				MaxTimestamp:    base.Add(time.Hour),
+				LogAppendTime:   true,
+				ProducerID:      3,
+				IsTransactional: true,
+				Records:         []*sarama.Record{{OffsetDelta: 0}},
+			}},
+			{RecordBatch: &sarama.RecordBatch{
+				FirstOffset:     6,
+				ProducerID:      3,
+				IsTransactional: true,
+				Control:         true,
+				Records:         []*sarama.Record{{Key: []byte{0, 0, 0, 1}}},
+			}},
+		},
+	}
+
+	var offsets []int64
+	records := make(map[int64]fetchedRecord)
+	next, partial := walkRecords(block, sarama.ReadCommitted, func(record fetchedRecord) {
+		offsets = append(offsets, record.offset)
+		records[record.offset] = record
+	})
+	assert.Equal(t, int64(7), next)
+	assert.False(t, partial)
+	assert.Equal(t, []int64{0, 1, 5}, offsets)
+	assert.Equal(t, []byte("key"), records[0].key)
+	assert.Equal(t, []byte("value"), records[0].value)
+	assert.Equal(t, []byte("trace"), records[0].headers[0].Key)
+	assert.Equal(t, base.Add(time.Second), records[1].timestamp)
+	assert.Equal(t, base.Add(time.Hour), records[5].timestamp)
+
+	offsets = nil
+	walkRecords(block, sarama.ReadUncommitted, func(record fetchedRecord) {
+		offsets = append(offsets, record.offset)
+	})
+	assert.Equal(t, []int64{0, 1, 2, 3, 5}, offsets)
+
+	// A batch cut by the fetch size is skipped and reported as partial.
+	block = &sarama.FetchResponseBlock{
+		RecordsSet: []*sarama.Records{
+			{RecordBatch: &sarama.RecordBatch{FirstOffset: 10, Records: []*sarama.Record{{OffsetDelta: 0}}}},
+			{RecordBatch: &sarama.RecordBatch{PartialTrailingRecord: true}},
+		},
+	}
+	offsets = nil
+	next, partial = walkRecords(block, sarama.ReadCommitted, func(record fetchedRecord) {
+		offsets = append(offsets, record.offset)
+	})
+	assert.Equal(t, int64(11), next)
+	assert.True(t, partial)
+	assert.Equal(t, []int64{10}, offsets)
+}
+
+func TestFetchVersion(t *testing.T) {
+	assert.Equal(t, int16(10), fetchVersion(sarama.V3_6_0_0))
+	assert.Equal(t, int16(5), fetchVersion(sarama.V0_11_0_0))
+	assert.Equal(t, int16(0), fetchVersion(sarama.V0_8_2_0))
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+)
+
+// brokerLimiter caps the number of requests in flight to a single broker.
+// Brokers are keyed by address, so brokers of different clusters sharing an ID are limited independently.
+type brokerLimiter struct {
+	capacity int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newBrokerLimiter creates a limiter allowing capacity requests per broker, non-positive capacity means no limit.
+func newBrokerLimiter(capacity int) *brokerLimiter {
+	return &brokerLimiter{
+		capacity: capacity,
+		slots:    make(map[string]chan struct{}),
+	}
+}
+
+// acquire waits for a free slot of the broker or until ctx is done, the returned func releases the slot.
+func (l *brokerLimiter) acquire(ctx context.Context, broker string) (func(), error) {
+	if l.capacity <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[broker]
+	if !ok {
+		slots = make(chan struct{}, l.capacity)
+		l.slots[broker] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
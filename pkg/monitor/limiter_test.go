@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerLimiter(t *testing.T) {
+	limiter := newBrokerLimiter(2)
+	ctx := context.Background()
+
+	release1, err := limiter.acquire(ctx, "broker-1:9092")
+	assert.NoError(t, err)
+	_, err = limiter.acquire(ctx, "broker-1:9092")
+	assert.NoError(t, err)
+
+	// Slots of other brokers are independent.
+	release3, err := limiter.acquire(ctx, "broker-2:9092")
+	assert.NoError(t, err)
+	release3()
+
+	// Broker 1 is full, acquire waits until ctx is done.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(timeoutCtx, "broker-1:9092")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	_, err = limiter.acquire(ctx, "broker-1:9092")
+	assert.NoError(t, err)
+}
+
+func TestBrokerLimiterUnlimited(t *testing.T) {
+	limiter := newBrokerLimiter(0)
+	for range 100 {
+		_, err := limiter.acquire(context.Background(), "broker-1:9092")
+		assert.NoError(t, err)
+	}
+}
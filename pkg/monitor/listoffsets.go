@@ -0,0 +1,309 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// Names of the supported topic checkers.
+const (
+	TopicCheckerConsume     = "consume"
+	TopicCheckerListOffsets = "list-offsets"
+)
+
+var (
+	ErrUnknownTopicChecker = errors.New("unknown topic checker")
+)
+
+// timestampSearchPrecision is the precision of last write times found by ListOffsetsTopicChecker.
+const timestampSearchPrecision = time.Second
+
+// noTimestamp is the timestamp of ListOffsets responses without a matching record.
+const noTimestamp int64 = -1
+
+// ListOffsetsTopicChecker finds the last write time of partitions with ListOffsets requests for timestamps instead
+// of consuming the last record, topics and their last reads are checked by KafkaTopicChecker.CheckTopic.
+//
+// ListOffsets returns the first offset written at or after a timestamp, so the latest timestamp of a partition is
+// searched for by bisecting time between the timestamp of the oldest record and the scan time, with partitions led
+// by the same broker looked up by a single request in every step. The max-timestamp lookup of ListOffsets v7 would
+// take a single request, but it is not supported by the Kafka client. Records with timestamps in the future are
+// found at the scan time at least. Partitions of topics written without timestamps fall back to fetching
+// the last record like KafkaTopicChecker does.
+type ListOffsetsTopicChecker struct {
+	*KafkaTopicChecker
+}
+
+var (
+	_ TopicChecker = &ListOffsetsTopicChecker{}
+)
+
+// NewListOffsetsTopicChecker creates a checker requiring brokers 0.10.1 or newer, arguments are the ones
+// of NewTopicChecker. The timeout of partition requests applies to every ListOffsets request.
+func NewListOffsetsTopicChecker(lastReadStrategy LastReadStrategy, maxInFlightPerBroker int, partitionTimeout time.Duration, lastRecordWindow int64) TopicChecker {
+	checker := &ListOffsetsTopicChecker{
+		KafkaTopicChecker: newKafkaTopicChecker(lastReadStrategy, maxInFlightPerBroker, partitionTimeout, lastRecordWindow),
+	}
+	checker.partitionsActivity = checker.getPartitionsActivity
+	return checker
+}
+
+// NewTopicCheckerByName creates the topic checker with the given name, consume based checker is used by default.
+func NewTopicCheckerByName(name string, lastReadStrategy LastReadStrategy, maxInFlightPerBroker int, partitionTimeout time.Duration, lastRecordWindow int64) (TopicChecker, error) {
+	switch name {
+	case "", TopicCheckerConsume:
+		return NewTopicChecker(lastReadStrategy, maxInFlightPerBroker, partitionTimeout, lastRecordWindow), nil
+	case TopicCheckerListOffsets:
+		return NewListOffsetsTopicChecker(lastReadStrategy, maxInFlightPerBroker, partitionTimeout, lastRecordWindow), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTopicChecker, name)
+	}
+}
+
+// getPartitionsActivity collects offsets and the last write time of all partitions with batched ListOffsets requests.
+func (c *ListOffsetsTopicChecker) getPartitionsActivity(ctx context.Context, kafkaClient sarama.Client, topicName string, partitions []int32) ([]report.PartitionActivityInfo, error) {
+	lookup := func(timestamps map[int32]int64) (map[int32]*sarama.OffsetResponseBlock, error) {
+		return c.listOffsets(ctx, kafkaClient, topicName, timestamps)
+	}
+
+	oldestOffsets, err := lookupOffsets(lookup, partitions, sarama.OffsetOldest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oldest offsets: %w", err)
+	}
+	newestOffsets, err := lookupOffsets(lookup, partitions, sarama.OffsetNewest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newest offsets: %w", err)
+	}
+
+	partitionInfos := make([]report.PartitionActivityInfo, 0, len(partitions))
+	var written []int32
+	for _, partition := range partitions {
+		oldestOffset, newestOffset := oldestOffsets[partition], newestOffsets[partition]
+		// Empty partitions were never written or retention deleted all their messages, there is no record to take the write time from.
+		empty := newestOffset <= oldestOffset
+		if !empty {
+			written = append(written, partition)
+		}
+		partitionInfo := report.PartitionActivityInfo{
+			Partition:        partition,
+			OldestOffset:     oldestOffset,
+			NewestOffset:     newestOffset,
+			Empty:            empty,
+			CommittedOffsets: make(map[string]int64),
+		}
+		if !empty {
+			partitionInfo.MessageCount = newestOffset - oldestOffset
+		}
+		partitionInfos = append(partitionInfos, partitionInfo)
+	}
+
+	lastTimestamps, err := searchLastTimestamps(func(timestamps map[int32]int64) (map[int32]int64, error) {
+		blocks, err := lookup(timestamps)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[int32]int64, len(blocks))
+		for partition, block := range blocks {
+			found[partition] = noTimestamp
+			if block.Offset >= 0 {
+				found[partition] = block.Timestamp
+			}
+		}
+		return found, nil
+	}, written, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search last write times: %w", err)
+	}
+
+	for i, partitionInfo := range partitionInfos {
+		if partitionInfo.Empty {
+			continue
+		}
+		timestamp := lastTimestamps[partitionInfo.Partition]
+		if timestamp != noTimestamp {
+			partitionInfos[i].LastWriteTime = time.UnixMilli(timestamp)
+			continue
+		}
+
+		// Records without timestamps can't be looked up by time, take the time of the last record instead.
+		if err := c.fetchLastWrite(ctx, kafkaClient, topicName, &partitionInfos[i]); err != nil {
+			return nil, err
+		}
+	}
+	return partitionInfos, nil
+}
+
+// fetchLastWrite finds the last write of the partition holding a request slot of the partition leader.
+func (c *ListOffsetsTopicChecker) fetchLastWrite(ctx context.Context, kafkaClient sarama.Client, topicName string, partitionInfo *report.PartitionActivityInfo) error {
+	ctx, cancel := withTimeout(ctx, c.partitionTimeout)
+	defer cancel()
+
+	release, err := c.acquireLeader(ctx, kafkaClient, topicName, partitionInfo.Partition)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return c.findLastWrite(ctx, kafkaClient, topicName, partitionInfo)
+}
+
+// listOffsets sends a single ListOffsets request for the timestamps of partitions to every leader of them.
+func (c *ListOffsetsTopicChecker) listOffsets(ctx context.Context, kafkaClient sarama.Client, topicName string, timestamps map[int32]int64) (map[int32]*sarama.OffsetResponseBlock, error) {
+	type leaderRequest struct {
+		request    *sarama.OffsetRequest
+		partitions []int32
+	}
+
+	requests := make(map[*sarama.Broker]*leaderRequest)
+	for partition, timestamp := range timestamps {
+		leader, err := callWithContext(ctx, func() (*sarama.Broker, error) {
+			return kafkaClient.Leader(topicName, partition)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get leader of partition %d: %w", partition, err)
+		}
+		request, ok := requests[leader]
+		if !ok {
+			request = &leaderRequest{request: &sarama.OffsetRequest{Version: 1}}
+			requests[leader] = request
+		}
+		request.request.AddBlock(topicName, partition, timestamp, 1)
+		request.partitions = append(request.partitions, partition)
+	}
+
+	var (
+		mu     sync.Mutex
+		blocks = make(map[int32]*sarama.OffsetResponseBlock, len(timestamps))
+		errs   []error
+		wg     sync.WaitGroup
+	)
+	for leader, request := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := c.sendListOffsets(ctx, leader, request.request)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			for _, partition := range request.partitions {
+				block := response.GetBlock(topicName, partition)
+				switch {
+				case block == nil:
+					errs = append(errs, fmt.Errorf("no offset of partition %d in response of broker %s", partition, leader.Addr()))
+				case block.Err != sarama.ErrNoError:
+					errs = append(errs, fmt.Errorf("failed to list offsets of partition %d: %w", partition, block.Err))
+				default:
+					blocks[partition] = block
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return blocks, nil
+}
+
+// sendListOffsets sends the request to the leader holding a request slot of it.
+func (c *ListOffsetsTopicChecker) sendListOffsets(ctx context.Context, leader *sarama.Broker, request *sarama.OffsetRequest) (*sarama.OffsetResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.partitionTimeout)
+	defer cancel()
+
+	release, err := c.brokerLimiter.acquire(ctx, leader.Addr())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	response, err := callWithContext(ctx, func() (*sarama.OffsetResponse, error) {
+		return leader.GetAvailableOffsets(request)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets on broker %s: %w", leader.Addr(), err)
+	}
+	return response, nil
+}
+
+// lookupOffsets returns offsets of all partitions for the timestamp, e.g. sarama.OffsetOldest.
+func lookupOffsets(lookup func(map[int32]int64) (map[int32]*sarama.OffsetResponseBlock, error), partitions []int32, timestamp int64) (map[int32]int64, error) {
+	timestamps := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		timestamps[partition] = timestamp
+	}
+
+	blocks, err := lookup(timestamps)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		offsets[partition] = blocks[partition].Offset
+	}
+	return offsets, nil
+}
+
+// searchLastTimestamps bisects time to find the latest record timestamp of each partition in milliseconds, noTimestamp
+// if records of the partition have no timestamps. lookup returns the timestamp of the first record written at or after
+// the timestamp of every partition or noTimestamp if there is no such record.
+func searchLastTimestamps(lookup func(map[int32]int64) (map[int32]int64, error), partitions []int32, now time.Time) (map[int32]int64, error) {
+	if len(partitions) == 0 {
+		return map[int32]int64{}, nil
+	}
+
+	// The timestamp of the oldest record is the lower bound and the scan time is the upper bound.
+	timestamps := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		timestamps[partition] = 0
+	}
+	found, err := lookup(timestamps)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		precision = timestampSearchPrecision.Milliseconds()
+		lower     = make(map[int32]int64, len(partitions))
+		upper     = make(map[int32]int64, len(partitions))
+	)
+	for _, partition := range partitions {
+		lower[partition] = found[partition]
+		upper[partition] = now.UnixMilli() + 1
+	}
+
+	for {
+		clear(timestamps)
+		for _, partition := range partitions {
+			if lower[partition] != noTimestamp && upper[partition]-lower[partition] > precision {
+				timestamps[partition] = lower[partition] + (upper[partition]-lower[partition])/2
+			}
+		}
+		if len(timestamps) == 0 {
+			return lower, nil
+		}
+
+		found, err := lookup(timestamps)
+		if err != nil {
+			return nil, err
+		}
+		for partition, timestamp := range timestamps {
+			if found[partition] == noTimestamp {
+				upper[partition] = timestamp
+				continue
+			}
+			lower[partition] = max(found[partition], timestamp)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTopicCheckerByName(t *testing.T) {
+	checker, err := NewTopicCheckerByName("", &MetadataLastReadStrategy{}, 0, 0, 1)
+	assert.NoError(t, err)
+	assert.IsType(t, &KafkaTopicChecker{}, checker)
+
+	checker, err = NewTopicCheckerByName(TopicCheckerListOffsets, &MetadataLastReadStrategy{}, 0, 0, 1)
+	assert.NoError(t, err)
+	assert.IsType(t, &ListOffsetsTopicChecker{}, checker)
+
+	_, err = NewTopicCheckerByName("unknown", &MetadataLastReadStrategy{}, 0, 0, 1)
+	assert.ErrorIs(t, err, ErrUnknownTopicChecker)
+}
+
+func TestSearchLastTimestamps(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Record timestamps of partitions in milliseconds, in the order of offsets.
+	logs := map[int32][]int64{
+		0: {now.Add(-72 * time.Hour).UnixMilli(), now.Add(-48 * time.Hour).UnixMilli(), now.Add(-90 * time.Minute).UnixMilli()},
+		// Out of order timestamps set by producers.
+		1: {now.Add(-72 * time.Hour).UnixMilli(), now.Add(-time.Hour).UnixMilli(), now.Add(-2 * time.Hour).UnixMilli()},
+		2: {now.Add(time.Hour).UnixMilli()},
+		3: {},
+	}
+
+	lookups := 0
+	lookup := func(timestamps map[int32]int64) (map[int32]int64, error) {
+		lookups++
+		found := make(map[int32]int64, len(timestamps))
+		for partition, timestamp := range timestamps {
+			found[partition] = noTimestamp
+			for _, recordTimestamp := range logs[partition] {
+				if recordTimestamp >= timestamp {
+					found[partition] = recordTimestamp
+					break
+				}
+			}
+		}
+		return found, nil
+	}
+
+	got, err := searchLastTimestamps(lookup, []int32{0, 1, 2, 3}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, logs[0][2], got[0])
+	assert.Equal(t, logs[1][1], got[1])
+	assert.Equal(t, logs[2][0], got[2])
+	assert.Equal(t, noTimestamp, got[3])
+	// All partitions are searched together, bisecting 3 days down to a second takes under 20 steps.
+	assert.LessOrEqual(t, lookups, 20)
+
+	got, err = searchLastTimestamps(lookup, nil, now)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
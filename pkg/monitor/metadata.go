@@ -0,0 +1,159 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/IBM/sarama"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// bytesPerGB is the number of bytes the storage cost is given for.
+const bytesPerGB = 1 << 30
+
+// Names of topic configs reported with topics.
+const (
+	configRetentionMs       = "retention.ms"
+	configRetentionBytes    = "retention.bytes"
+	configCleanupPolicy     = "cleanup.policy"
+	configMinInSyncReplicas = "min.insync.replicas"
+)
+
+// DescribeTopic fills retention, cleanup policy and replication settings of the topic.
+func (c *Cluster) DescribeTopic(ctx context.Context, topicName string, info *report.TopicActivityInfo) error {
+	entries, err := callWithContext(ctx, func() ([]sarama.ConfigEntry, error) {
+		return c.admin.DescribeConfig(sarama.ConfigResource{
+			Type:        sarama.TopicResource,
+			Name:        topicName,
+			ConfigNames: []string{configRetentionMs, configRetentionBytes, configCleanupPolicy, configMinInSyncReplicas},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe configs of topic %s: %w", topicName, err)
+	}
+	if err := applyTopicConfig(info, entries); err != nil {
+		return fmt.Errorf("failed to parse configs of topic %s: %w", topicName, err)
+	}
+
+	partitions, err := callWithContext(ctx, func() ([]int32, error) {
+		return c.client.Partitions(topicName)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get partitions for topic %s: %w", topicName, err)
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+	replicas, err := callWithContext(ctx, func() ([]int32, error) {
+		return c.client.Replicas(topicName, partitions[0])
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get replicas of partition %d: %w", partitions[0], err)
+	}
+	info.ReplicationFactor = len(replicas)
+	return nil
+}
+
+// IsInternalTopic reports if brokers mark the topic as internal, e.g. __consumer_offsets.
+func (c *Cluster) IsInternalTopic(ctx context.Context, topicName string) (bool, error) {
+	metadata, err := callWithContext(ctx, func() ([]*sarama.TopicMetadata, error) {
+		return c.admin.DescribeTopics([]string{topicName})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to describe topic %s: %w", topicName, err)
+	}
+	if len(metadata) == 0 {
+		return false, fmt.Errorf("failed to describe topic %s: %w", topicName, sarama.ErrUnknownTopicOrPartition)
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return false, fmt.Errorf("failed to describe topic %s: %w", topicName, metadata[0].Err)
+	}
+	return metadata[0].IsInternal, nil
+}
+
+// PartitionSizes returns the size of all replicas of every partition on disks of the brokers by topic. Brokers failing
+// to describe their log directories are missing from the sizes, the error of the first of them is returned with the sizes.
+func (c *Cluster) PartitionSizes(ctx context.Context) (map[string]map[int32]int64, error) {
+	brokers := c.client.Brokers()
+	brokerIDs := make([]int32, 0, len(brokers))
+	for _, broker := range brokers {
+		brokerIDs = append(brokerIDs, broker.ID())
+	}
+
+	type result struct {
+		logDirs map[int32][]sarama.DescribeLogDirsResponseDirMetadata
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		logDirs, err := c.admin.DescribeLogDirs(brokerIDs)
+		done <- result{logDirs: logDirs, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			r.err = fmt.Errorf("failed to describe log dirs: %w", r.err)
+		}
+		return sumPartitionSizes(r.logDirs), r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to describe log dirs: %w", ctx.Err())
+	}
+}
+
+// applyTopicConfig sets topic settings from the config entries of the topic.
+func applyTopicConfig(info *report.TopicActivityInfo, entries []sarama.ConfigEntry) error {
+	for _, entry := range entries {
+		var err error
+		switch entry.Name {
+		case configRetentionMs:
+			info.RetentionMs, err = strconv.ParseInt(entry.Value, 10, 64)
+		case configRetentionBytes:
+			info.RetentionBytes, err = strconv.ParseInt(entry.Value, 10, 64)
+		case configCleanupPolicy:
+			info.CleanupPolicy = entry.Value
+		case configMinInSyncReplicas:
+			info.MinInSyncReplicas, err = strconv.Atoi(entry.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", entry.Name, entry.Value, err)
+		}
+	}
+	return nil
+}
+
+// sumPartitionSizes sums sizes of replicas of every partition across log directories of brokers, including
+// future replicas being moved between directories.
+func sumPartitionSizes(logDirs map[int32][]sarama.DescribeLogDirsResponseDirMetadata) map[string]map[int32]int64 {
+	sizes := make(map[string]map[int32]int64)
+	for _, dirs := range logDirs {
+		for _, dir := range dirs {
+			if dir.ErrorCode != sarama.ErrNoError {
+				continue
+			}
+			for _, topic := range dir.Topics {
+				if sizes[topic.Topic] == nil {
+					sizes[topic.Topic] = make(map[int32]int64, len(topic.Partitions))
+				}
+				for _, partition := range topic.Partitions {
+					sizes[topic.Topic][partition.PartitionID] += partition.Size
+				}
+			}
+		}
+	}
+	return sizes
+}
+
+// applySizes sets sizes of the topic and its partitions and the cost of storing the topic.
+func applySizes(info *report.TopicActivityInfo, partitionSizes map[int32]int64, storageCostPerGB float64) {
+	info.SizeBytes = 0
+	for _, size := range partitionSizes {
+		info.SizeBytes += size
+	}
+	for i := range info.Partitions {
+		info.Partitions[i].SizeBytes = partitionSizes[info.Partitions[i].Partition]
+	}
+	info.StorageCost = float64(info.SizeBytes) / bytesPerGB * storageCostPerGB
+}
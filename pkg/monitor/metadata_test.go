@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestApplyTopicConfig(t *testing.T) {
+	info := &report.TopicActivityInfo{}
+	err := applyTopicConfig(info, []sarama.ConfigEntry{
+		{Name: configRetentionMs, Value: "604800000"},
+		{Name: configRetentionBytes, Value: "-1"},
+		{Name: configCleanupPolicy, Value: "compact"},
+		{Name: configMinInSyncReplicas, Value: "2"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(604800000), info.RetentionMs)
+	assert.Equal(t, int64(-1), info.RetentionBytes)
+	assert.Equal(t, "compact", info.CleanupPolicy)
+	assert.Equal(t, 2, info.MinInSyncReplicas)
+
+	err = applyTopicConfig(info, []sarama.ConfigEntry{{Name: configRetentionMs, Value: "week"}})
+	assert.Error(t, err)
+}
+
+func TestSumPartitionSizes(t *testing.T) {
+	logDirs := map[int32][]sarama.DescribeLogDirsResponseDirMetadata{
+		1: {{
+			Path: "/data/1",
+			Topics: []sarama.DescribeLogDirsResponseTopic{
+				{Topic: "topic-a", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 0, Size: 100}, {PartitionID: 1, Size: 50}}},
+			},
+		}},
+		2: {
+			{
+				Path: "/data/1",
+				Topics: []sarama.DescribeLogDirsResponseTopic{
+					{Topic: "topic-a", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 0, Size: 100}}},
+					{Topic: "topic-b", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 0, Size: 10}}},
+				},
+			},
+			{Path: "/data/2", ErrorCode: sarama.ErrKafkaStorageError},
+		},
+	}
+	assert.Equal(t, map[string]map[int32]int64{"topic-a": {0: 200, 1: 50}, "topic-b": {0: 10}}, sumPartitionSizes(logDirs))
+}
+
+func TestApplySizes(t *testing.T) {
+	info := &report.TopicActivityInfo{
+		Partitions: []report.PartitionActivityInfo{{Partition: 0}, {Partition: 1}, {Partition: 2}},
+	}
+	applySizes(info, map[int32]int64{0: 3 << 30, 1: 1 << 30}, 0.5)
+	assert.Equal(t, int64(4<<30), info.SizeBytes)
+	assert.Equal(t, []int64{3 << 30, 1 << 30, 0}, []int64{info.Partitions[0].SizeBytes, info.Partitions[1].SizeBytes, info.Partitions[2].SizeBytes})
+	assert.InDelta(t, 2.0, info.StorageCost, 1e-9)
+
+	// Sizes of topics not described are unknown.
+	applySizes(info, nil, 0.5)
+	assert.Zero(t, info.SizeBytes)
+	assert.Zero(t, info.StorageCost)
+}
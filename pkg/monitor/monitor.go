@@ -2,78 +2,112 @@ package monitor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
 
+	"kafka-topic-monitor/pkg/audit"
+	"kafka-topic-monitor/pkg/history"
 	. "kafka-topic-monitor/pkg/logger"
 	"kafka-topic-monitor/pkg/monitor/report"
 )
 
+var (
+	ErrNoScan          = errors.New("no scan has completed yet")
+	ErrHistoryDisabled = errors.New("history is disabled")
+	ErrNoClusters      = errors.New("no clusters to monitor")
+	ErrUnknownCluster  = errors.New("unknown cluster")
+	ErrClusterRequired = errors.New("cluster is required when several clusters are monitored")
+)
+
 // Monitor struct to manage Kafka connections and operations
 type Monitor struct {
-	BootstrapServers []string
-	ListenAddr       string
-	InactivityDays   int
+	ListenAddr   string
+	ScanInterval time.Duration
+	ScanWorkers  int
+
+	clusters []*Cluster
+	filter   *TopicFilter
+	rules    []InactivityRule
 
-	client sarama.Client
-	admin  sarama.ClusterAdmin
+	reporters *ReporterRegistry
+	history   HistoryStore
+	cleaner   *Cleaner
+	notifier  Notifier
 
-	checker  TopicChecker
-	reporter Reporter
+	// lastScan is the latest completed scan, it is accessed only from Start loop.
+	lastScan *report.Scan
+	// states are the latest checked states of topics transitions are found against, accessed only from Start loop.
+	states *topicStates
 
-	reportTaskChan chan chan []byte
+	scans       atomic.Uint64
+	scanErrors  atomic.Uint64
+	topicErrors atomic.Uint64
+
+	reportTaskChan     chan reportQuery
+	clustersTaskChan   chan clustersQuery
+	groupsTaskChan     chan groupsQuery
+	historyTaskChan    chan historyQuery
+	cleanupTaskChan    chan cleanupQuery
+	quarantineTaskChan chan quarantineQuery
+	refreshTaskChan    chan struct{}
+	scanResultChan     chan *report.Scan
 }
 
 type TopicChecker interface {
-	CheckTopic(context.Context, string, sarama.Client, sarama.ClusterAdmin) (*report.TopicActivityInfo, error)
+	CheckTopic(context.Context, string, sarama.Client, GroupOffsets) (*report.TopicActivityInfo, error)
 }
 
 type Reporter interface {
-	Report([]*report.TopicActivityInfo) ([]byte, error)
+	Report(*report.Scan) ([]byte, error)
 }
 
-// NewMonitor creates a new Monitor instance
-func NewMonitor(servers []string, inActivityDays int, ListenAddr string, checker TopicChecker, reporter Reporter) (*Monitor, error) {
-	config := sarama.NewConfig()
-	config.Version = sarama.V4_0_0_0
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
-	config.Consumer.Offsets.AutoCommit.Enable = false
-	config.Net.SASL.Enable = false
-	config.Net.TLS.Enable = false
-
-	client, err := sarama.NewClient(servers, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
-	}
+// HistoryStore persists scans so activity of topics survives restarts and Kafka retention.
+type HistoryStore interface {
+	Record(*report.Scan) error
+	Apply(*report.TopicActivityInfo)
+	TopicSnapshots(cluster, topicName string, from, to time.Time) ([]history.TopicSnapshot, error)
+	Summary(from, to time.Time) ([]history.TopicSummary, error)
+}
 
-	admin, err := sarama.NewClusterAdminFromClient(client)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka cluster admin: %w", err)
+// NewMonitor creates a new Monitor instance scanning topics of the clusters allowed by filter
+// filter and history are optional, all topics are checked if filter is nil and scans are not persisted if history is nil.
+// Topics are classified by the first matching rule, inactivity days of the cluster apply to topics matching no rule.
+// cleaner is optional, cleanup of inactive topics is disabled if it is nil.
+// notifier is optional, it receives state transitions of topics found by scans.
+func NewMonitor(clusters []*Cluster, filter *TopicFilter, rules []InactivityRule, scanInterval time.Duration, scanWorkers int, ListenAddr string, reporters *ReporterRegistry, history HistoryStore, cleaner *Cleaner, notifier Notifier) (*Monitor, error) {
+	if len(clusters) == 0 {
+		return nil, ErrNoClusters
 	}
 
 	return &Monitor{
-		BootstrapServers: servers,
-		ListenAddr:       ListenAddr,
-		InactivityDays:   inActivityDays,
-
-		client:         client,
-		admin:          admin,
-		checker:        checker,
-		reporter:       reporter,
-		reportTaskChan: make(chan chan []byte),
-	}, nil
-}
+		ListenAddr:   ListenAddr,
+		ScanInterval: scanInterval,
+		ScanWorkers:  scanWorkers,
 
-// ListTopics lists the Kafka topics available in the connected cluster
-func (m *Monitor) ListTopics() ([]string, error) {
-	topics, err := m.client.Topics()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list topics: %w", err)
-	}
-	return topics, nil
+		clusters:           clusters,
+		filter:             filter,
+		rules:              rules,
+		reporters:          reporters,
+		history:            history,
+		cleaner:            cleaner,
+		notifier:           notifier,
+		states:             newTopicStates(),
+		reportTaskChan:     make(chan reportQuery),
+		clustersTaskChan:   make(chan clustersQuery),
+		groupsTaskChan:     make(chan groupsQuery),
+		historyTaskChan:    make(chan historyQuery),
+		cleanupTaskChan:    make(chan cleanupQuery),
+		quarantineTaskChan: make(chan quarantineQuery),
+		refreshTaskChan:    make(chan struct{}, 1),
+		scanResultChan:     make(chan *report.Scan),
+	}, nil
 }
 
 // Start initiates the monitoring loop
@@ -81,73 +115,578 @@ func (m *Monitor) Start(ctx context.Context) {
 	GetLogger().Infof("Starting Kafka Monitor...")
 	defer m.Close() // Ensure the client is closed when exiting the loop
 	// Start the HTTP server
-	if err := StartHTTPServer(ctx, m.ListenAddr, m.reportTaskChan); err != nil {
+	if err := StartHTTPServer(ctx, m.ListenAddr, m.reporters, m.reportTaskChan, m.clustersTaskChan, m.groupsTaskChan, m.historyTaskChan, m.cleanupTaskChan, m.quarantineTaskChan, m.refreshTaskChan); err != nil {
 		GetLogger().Fatalf("Failed to start HTTP server: %v\n", err)
 	}
+	go m.scanLoop(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			GetLogger().Infof("Shutdown signal received.")
 			m.Close()
 			return
-		case reportChan := <-m.reportTaskChan:
-			topics, err := m.ListTopics()
-			if err != nil {
-				GetLogger().Infof("Failed to list topics: %v\n", err)
-				continue // Proceed to the next iteration
+		case scan := <-m.scanResultChan:
+			// The first scan has nothing to compare with, topics existing at startup are not reported as created.
+			if m.notifier != nil {
+				if events := m.states.update(scan); len(events) > 0 {
+					m.notifier.Notify(events)
+				}
+			}
+			m.lastScan = scan
+		case query := <-m.reportTaskChan:
+			query.response <- m.reportScan(query)
+		case query := <-m.clustersTaskChan:
+			reportBytes, err := m.reportClusters()
+			query.response <- clustersResponse{report: reportBytes, err: err}
+		case query := <-m.groupsTaskChan:
+			reportBytes, err := m.reportTopicGroups(query.cluster, query.topic)
+			query.response <- groupsResponse{report: reportBytes, err: err}
+		case query := <-m.historyTaskChan:
+			// History is read from file, don't block the loop while reading it.
+			go func() {
+				reportBytes, err := m.reportHistory(query)
+				query.response <- historyResponse{report: reportBytes, err: err}
+			}()
+		case query := <-m.cleanupTaskChan:
+			// Deleting topics takes a while, don't block the loop while deleting them.
+			scan := m.lastScan
+			go func() {
+				query.response <- m.cleanup(ctx, scan, query)
+			}()
+		case query := <-m.quarantineTaskChan:
+			// Reverting alters configs of the topic, don't block the loop while altering them.
+			go func() {
+				reportBytes, err := m.reportQuarantine(ctx, query)
+				query.response <- quarantineResponse{report: reportBytes, err: err}
+			}()
+		}
+	}
+}
+
+// scanLoop scans the clusters right away and then every ScanInterval or when a refresh is requested,
+// completed scans are sent to Start loop.
+func (m *Monitor) scanLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		scan, err := m.scan(ctx)
+		if err != nil {
+			GetLogger().Errorf("failed to scan topics: %v", err)
+		} else {
+			m.scans.Add(1)
+			select {
+			case m.scanResultChan <- scan:
+			case <-ctx.Done():
+				return
 			}
-			var (
-				resultChan = make(chan *report.TopicActivityInfo, len(topics))
-				wg         sync.WaitGroup
-			)
-			wg.Add(len(topics))
-			for _, topic := range topics {
-				go func() {
-					defer wg.Done()
-					info, err := m.checker.CheckTopic(ctx, topic, m.client, m.admin)
-					if err != nil {
-						GetLogger().Errorf("failed to check topic %s: %v", topic, err)
-						return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.refreshTaskChan:
+			ticker.Reset(m.ScanInterval)
+		}
+	}
+}
+
+// scan checks all topics of all clusters concurrently, it fails only if topics of no cluster could be listed
+func (m *Monitor) scan(ctx context.Context) (*report.Scan, error) {
+	scannedAt := time.Now()
+
+	var (
+		clusterInfos = make([]report.ClusterInfo, len(m.clusters))
+		topics       = make([][]*report.TopicActivityInfo, len(m.clusters))
+		wg           sync.WaitGroup
+	)
+	wg.Add(len(m.clusters))
+	for i, cluster := range m.clusters {
+		go func() {
+			defer wg.Done()
+			clusterInfos[i], topics[i] = m.scanCluster(ctx, cluster, scannedAt)
+		}()
+	}
+	wg.Wait()
+	// Workers stop taking topics when the scan is cancelled, a partial scan would look like topics were deleted.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	scan := &report.Scan{
+		ScannedAt: scannedAt,
+		Duration:  time.Since(scannedAt),
+		Clusters:  clusterInfos,
+	}
+
+	var errs []error
+	for i, clusterInfo := range clusterInfos {
+		if clusterInfo.Error != "" {
+			errs = append(errs, fmt.Errorf("cluster %s: %s", clusterInfo.Name, clusterInfo.Error))
+		}
+		scan.Topics = append(scan.Topics, topics[i]...)
+	}
+	if len(errs) == len(m.clusters) {
+		return nil, errors.Join(errs...)
+	}
+
+	if m.history != nil {
+		if err := m.history.Record(scan); err != nil {
+			GetLogger().Errorf("failed to record scan in history: %v", err)
+		}
+	}
+	return scan, nil
+}
+
+// scanCluster checks all topics of the cluster with ScanWorkers workers
+func (m *Monitor) scanCluster(ctx context.Context, cluster *Cluster, scannedAt time.Time) (report.ClusterInfo, []*report.TopicActivityInfo) {
+	clusterInfo := report.ClusterInfo{
+		Name:             cluster.Name,
+		BootstrapServers: cluster.BootstrapServers,
+	}
+	clusterStart := time.Now()
+
+	allTopics, err := cluster.ListTopics()
+	if err != nil {
+		m.scanErrors.Add(1)
+		GetLogger().Errorf("failed to scan cluster %s: %v", cluster.Name, err)
+		clusterInfo.Error = err.Error()
+		return clusterInfo, nil
+	}
+	topics := m.filter.Filter(allTopics)
+	GetLogger().Debugf("skipped %d of %d topics of cluster %s by filter", len(allTopics)-len(topics), len(allTopics), cluster.Name)
+
+	// Offsets committed by consumer groups are fetched for all topics by a single request per group,
+	// checks of all topics fail without them since their last reads are unknown.
+	groupOffsets, groupsErr := cluster.ConsumerGroupOffsets(ctx, topics)
+	if groupsErr != nil {
+		GetLogger().Errorf("failed to get consumer group offsets of cluster %s: %v", cluster.Name, groupsErr)
+	}
+
+	// Sizes of partitions are described for all topics by a single request per broker.
+	sizes, err := cluster.PartitionSizes(ctx)
+	if err != nil {
+		GetLogger().Warnf("failed to get partition sizes of cluster %s: %v", cluster.Name, err)
+	}
+
+	var (
+		topicChan  = make(chan string, len(topics))
+		resultChan = make(chan *report.TopicActivityInfo, len(topics))
+		wg         sync.WaitGroup
+	)
+	for _, topic := range topics {
+		topicChan <- topic
+	}
+	close(topicChan)
+
+	workers := min(max(m.ScanWorkers, 1), len(topics))
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for topic := range topicChan {
+				if ctx.Err() != nil {
+					return
+				}
+				checkStart := time.Now()
+				var (
+					info *report.TopicActivityInfo
+					err  error
+				)
+				if groupsErr != nil {
+					err = fmt.Errorf("error getting last read of topic %s: %w", topic, groupsErr)
+				} else {
+					info, err = cluster.CheckTopic(ctx, topic, groupOffsets)
+				}
+				checkDuration := time.Since(checkStart)
+				GetLogger().Debugf("checked topic %s of cluster %s in %v", topic, cluster.Name, checkDuration)
+				if err != nil {
+					// Report the topic with the error and its activity known from history, so it doesn't disappear from the report.
+					m.topicErrors.Add(1)
+					GetLogger().Errorf("failed to check topic %s of cluster %s: %v", topic, cluster.Name, err)
+					info = &report.TopicActivityInfo{
+						Error:      err.Error(),
+						ErrorClass: classifyError(err),
 					}
-					info.Active = isActive(info.LastWriteTime, info.LastReadTime, m.InactivityDays)
-					info.TopicName = topic
-					resultChan <- info
-				}()
+				}
+				applySizes(info, sizes[topic], cluster.StorageCostPerGB)
+				info.Cluster = cluster.Name
+				info.TopicName = topic
+				info.CheckDuration = checkDuration
+				m.classifyTopic(cluster, info, scannedAt)
+				if info.Status.Reclaimable() {
+					info.ReclaimableBytes = info.SizeBytes
+				}
+				resultChan <- info
 			}
+		}()
+	}
 
-			wg.Wait()
-			topicActivityInfos := drainChannel[*report.TopicActivityInfo](resultChan)
+	wg.Wait()
+	clusterInfo.Duration = time.Since(clusterStart)
 
-			reportBytes, err := m.reporter.Report(topicActivityInfos)
-			if err != nil {
-				GetLogger().Errorf("failed to report topics: %v", err)
-				continue
+	result := drainChannel[*report.TopicActivityInfo](resultChan)
+	// Topics left unchecked by a cancelled scan were not seen, yet they still exist.
+	if ctx.Err() == nil {
+		failed := make(map[string]bool)
+		for _, info := range result {
+			if info.Failed() {
+				failed[info.TopicName] = true
 			}
-			reportChan <- reportBytes
 		}
+		cluster.endScan(failed)
+	}
+	summary := report.Summarize(result)
+	GetLogger().Infof("Scanned %d topics of cluster %s with %d workers in %v, %d failed", summary.Topics, cluster.Name, workers, clusterInfo.Duration, summary.Failed)
+	clusterInfo.TopicNumber = summary.Topics
+	clusterInfo.FailedTopics = summary.Failed
+	for _, info := range result {
+		clusterInfo.SizeBytes += info.SizeBytes
+		clusterInfo.ReclaimableBytes += info.ReclaimableBytes
 	}
+	return clusterInfo, result
 }
 
-// Close shuts down the Kafka client connection
-func (m *Monitor) Close() {
-	if err := m.client.Close(); err != nil {
-		GetLogger().Infof("Error closing Kafka client: %v\n", err)
+// classifyTopic completes the checked topic with its activity known from history and sets its status by the first
+// inactivity rule matching it.
+func (m *Monitor) classifyTopic(cluster *Cluster, info *report.TopicActivityInfo, scannedAt time.Time) {
+	if m.history != nil {
+		m.history.Apply(info)
+	}
+	if info.FirstSeenTime.IsZero() {
+		info.FirstSeenTime = scannedAt
+	}
+	rule := inactivityRule(m.rules, cluster, info.TopicName)
+	info.InactivityRule = rule.Name
+	info.WriteInactivity = rule.WriteInactivity
+	info.ReadInactivity = rule.ReadInactivity
+	info.Status = topicStatus(info, rule, time.Now())
+	info.Active = info.Status.Active()
+	if info.Active {
+		info.LastActiveTime = scannedAt
+	}
+}
+
+// counters returns cumulative counters of scans
+func (m *Monitor) counters() report.ScanCounters {
+	return report.ScanCounters{
+		Scans:       m.scans.Load(),
+		ScanErrors:  m.scanErrors.Load(),
+		TopicErrors: m.topicErrors.Load(),
+	}
+}
+
+// reportScan returns report of the latest scan, limited to a single cluster if the query names one
+// and to topics allowed by the filter of the query, in the order of the query
+func (m *Monitor) reportScan(query reportQuery) reportResponse {
+	if query.cluster != "" && m.cluster(query.cluster) == nil {
+		return reportResponse{err: fmt.Errorf("%w: %s", ErrUnknownCluster, query.cluster)}
+	}
+	if m.lastScan == nil {
+		return reportResponse{err: ErrNoScan}
 	}
 
-	if err := m.admin.Close(); err != nil {
-		GetLogger().Infof("Error closing Kafka admin: %v\n", err)
+	scan := *m.lastScan
+	if query.cluster != "" {
+		clusterScan, ok := m.lastScan.Cluster(query.cluster)
+		if !ok {
+			return reportResponse{err: fmt.Errorf("%w: %s", ErrUnknownCluster, query.cluster)}
+		}
+		scan = *clusterScan
+	}
+	if query.filter != nil {
+		scan.Topics = slices.DeleteFunc(slices.Clone(scan.Topics), func(info *report.TopicActivityInfo) bool {
+			return !query.filter.Allow(info.TopicName)
+		})
+	}
+	if query.order != nil {
+		scan.Topics = slices.Clone(scan.Topics)
+		slices.SortStableFunc(scan.Topics, query.order)
+	}
+	scan.Counters = m.counters()
+	scan.Summary = report.Summarize(scan.Topics)
+
+	reportBytes, err := query.reporter.Report(&scan)
+	if err != nil {
+		GetLogger().Errorf("failed to report topics: %v", err)
 	}
+	return reportResponse{report: reportBytes, scannedAt: scan.ScannedAt, summary: scan.Summary, err: err}
 }
 
-// isActive checks if the topic is active based on the last write and read times.
-func isActive(lastWriteTime, lastReadTime time.Time, inactivityDays int) bool {
-	// Check if the topic is active based on the last write and read times
-	if lastWriteTime.IsZero() && lastReadTime.IsZero() {
-		return false
+// reportClusters returns monitored clusters with outcome of the latest scan as JSON
+func (m *Monitor) reportClusters() ([]byte, error) {
+	clusterInfos := make([]report.ClusterInfo, 0, len(m.clusters))
+	if m.lastScan != nil {
+		clusterInfos = append(clusterInfos, m.lastScan.Clusters...)
+	} else {
+		for _, cluster := range m.clusters {
+			clusterInfos = append(clusterInfos, report.ClusterInfo{
+				Name:             cluster.Name,
+				BootstrapServers: cluster.BootstrapServers,
+			})
+		}
+	}
+
+	reportBytes, err := json.MarshalIndent(clusterInfos, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling clusters: %w", err)
+	}
+	return reportBytes, nil
+}
+
+// cluster returns the monitored cluster with the name or nil
+func (m *Monitor) cluster(name string) *Cluster {
+	for _, cluster := range m.clusters {
+		if cluster.Name == name {
+			return cluster
+		}
 	}
+	return nil
+}
 
-	inactivityDuration := time.Duration(inactivityDays) * 24 * time.Hour
-	return time.Since(lastWriteTime) < inactivityDuration || time.Since(lastReadTime) < inactivityDuration
+// resolveCluster returns the name of the monitored cluster, the name may be omitted if a single cluster is monitored
+func (m *Monitor) resolveCluster(name string) (string, error) {
+	if name == "" {
+		if len(m.clusters) != 1 {
+			return "", ErrClusterRequired
+		}
+		return m.clusters[0].Name, nil
+	}
+	if m.cluster(name) == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+	return name, nil
+}
+
+// reportTopicGroups returns consumer groups lag of a topic from the latest scan as JSON
+func (m *Monitor) reportTopicGroups(cluster, topic string) ([]byte, error) {
+	cluster, err := m.resolveCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if m.lastScan == nil {
+		return nil, ErrNoScan
+	}
+
+	for _, info := range m.lastScan.Topics {
+		if info.Cluster != cluster || info.TopicName != topic {
+			continue
+		}
+
+		reportBytes, err := json.MarshalIndent(info.ConsumerGroups, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling consumer groups of topic %s: %w", topic, err)
+		}
+		return reportBytes, nil
+	}
+	return nil, fmt.Errorf("topic %s of cluster %s: %w", topic, cluster, sarama.ErrUnknownTopicOrPartition)
+}
+
+// reportHistory returns history of a topic or summary of all topics as JSON, summary is limited
+// to a single cluster if the query names one
+func (m *Monitor) reportHistory(query historyQuery) ([]byte, error) {
+	if m.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	var (
+		result any
+		err    error
+	)
+	if query.topic != "" {
+		var cluster string
+		if cluster, err = m.resolveCluster(query.cluster); err != nil {
+			return nil, err
+		}
+		result, err = m.history.TopicSnapshots(cluster, query.topic, query.from, query.to)
+	} else {
+		if query.cluster != "" && m.cluster(query.cluster) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, query.cluster)
+		}
+		var summaries []history.TopicSummary
+		summaries, err = m.history.Summary(query.from, query.to)
+		if query.cluster != "" {
+			summaries = slices.DeleteFunc(summaries, func(summary history.TopicSummary) bool {
+				return summary.Cluster != query.cluster
+			})
+		}
+		result = summaries
+	}
+	if err != nil {
+		if errors.Is(err, ErrClusterRequired) || errors.Is(err, ErrUnknownCluster) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+
+	reportBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling history: %w", err)
+	}
+	return reportBytes, nil
+}
+
+// cleanup runs the cleaner on topics of the scan. Dry runs respond with the planned actions once they are audited,
+// other runs respond with the planned actions right away and take them in background, archiving and deleting topics
+// takes longer than requests may. Runs which are not dry runs are refused if the scan completed more than two scan
+// intervals ago, e.g. when scans keep failing.
+func (m *Monitor) cleanup(ctx context.Context, scan *report.Scan, query cleanupQuery) cleanupResponse {
+	if m.cleaner == nil {
+		return cleanupResponse{err: ErrCleanupDisabled}
+	}
+	if scan == nil {
+		return cleanupResponse{err: ErrNoScan}
+	}
+	if age := time.Since(scan.ScannedAt.Add(scan.Duration)); !query.dryRun && age > 2*m.ScanInterval {
+		return cleanupResponse{err: fmt.Errorf("%w: completed %v ago", ErrStaleScan, age.Round(time.Second))}
+	}
+
+	now := time.Now()
+	entries, err := m.cleaner.start(scan, now, query.dryRun, query.token)
+	if err != nil {
+		return cleanupResponse{err: err}
+	}
+	if query.dryRun {
+		entries, err = m.cleaner.execute(ctx, entries, now, true, m)
+		if err != nil {
+			return cleanupResponse{err: err}
+		}
+	} else {
+		go m.executeCleanup(ctx, slices.Clone(entries), now)
+	}
+
+	reportBytes, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return cleanupResponse{err: fmt.Errorf("error marshaling cleanup actions: %w", err)}
+	}
+	return cleanupResponse{report: reportBytes, accepted: !query.dryRun}
+}
+
+// executeCleanup takes the planned actions of a cleanup run and requests a new scan if topics were deleted
+// or quarantined, so reports don't list them anymore or list their new retention.
+func (m *Monitor) executeCleanup(ctx context.Context, entries []audit.Entry, now time.Time) {
+	entries, err := m.cleaner.execute(ctx, entries, now, false, m)
+	if err != nil {
+		GetLogger().Errorf("cleanup run stopped: %v", err)
+	}
+	if slices.ContainsFunc(entries, func(entry audit.Entry) bool {
+		return entry.Action == ActionDelete || entry.Action == ActionQuarantine
+	}) {
+		select {
+		case m.refreshTaskChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// reportQuarantine returns quarantined topics as JSON, or reverts the quarantine of the topic of the query
+// and returns the audit entry of the revert.
+func (m *Monitor) reportQuarantine(ctx context.Context, query quarantineQuery) ([]byte, error) {
+	if m.cleaner == nil {
+		return nil, ErrQuarantineDisabled
+	}
+
+	var value any
+	if query.topic == "" {
+		entries, err := m.cleaner.Quarantined()
+		if err != nil {
+			return nil, err
+		}
+		value = entries
+	} else {
+		cluster, err := m.resolveCluster(query.cluster)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := m.cleaner.Revert(ctx, cluster, query.topic, query.token, m)
+		if err != nil {
+			return nil, err
+		}
+		value = entry
+	}
+
+	reportBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling quarantine report: %w", err)
+	}
+	return reportBytes, nil
+}
+
+// archiveTopic archives the topic of the named cluster to dir
+func (m *Monitor) archiveTopic(ctx context.Context, clusterName, topic, dir string) error {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	_, err := cluster.ArchiveTopic(ctx, topic, dir)
+	return err
+}
+
+// deleteTopic deletes the topic of the named cluster
+func (m *Monitor) deleteTopic(ctx context.Context, clusterName, topic string) error {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	return cluster.DeleteTopic(ctx, topic)
+}
+
+// quarantineTopic lowers retention of the topic of the named cluster
+func (m *Monitor) quarantineTopic(ctx context.Context, clusterName, topic string, retention time.Duration) (map[string]string, map[string]*string, error) {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	return cluster.QuarantineTopic(ctx, topic, retention)
+}
+
+// revertTopic restores original configs of the quarantined topic of the named cluster
+func (m *Monitor) revertTopic(ctx context.Context, clusterName, topic string, original map[string]*string) error {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	return cluster.RevertTopic(ctx, topic, original)
+}
+
+// checkTopic checks the topic of the named cluster on its own and classifies it like scans do
+func (m *Monitor) checkTopic(ctx context.Context, clusterName, topic string) (*report.TopicActivityInfo, error) {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	checkedAt := time.Now()
+	groupOffsets, err := cluster.ConsumerGroupOffsets(ctx, []string{topic})
+	if err != nil {
+		return nil, fmt.Errorf("error getting last read of topic %s: %w", topic, err)
+	}
+	info, err := cluster.CheckTopic(ctx, topic, groupOffsets)
+	if err != nil {
+		return nil, err
+	}
+	info.Cluster = cluster.Name
+	info.TopicName = topic
+	info.CheckDuration = time.Since(checkedAt)
+	m.classifyTopic(cluster, info, checkedAt)
+	return info, nil
+}
+
+// isInternalTopic reports if the topic of the named cluster is internal to Kafka
+func (m *Monitor) isInternalTopic(ctx context.Context, clusterName, topic string) (bool, error) {
+	cluster := m.cluster(clusterName)
+	if cluster == nil {
+		return false, fmt.Errorf("%w: %s", ErrUnknownCluster, clusterName)
+	}
+	return cluster.IsInternalTopic(ctx, topic)
+}
+
+// Close shuts down Kafka client connections of all clusters
+func (m *Monitor) Close() {
+	for _, cluster := range m.clusters {
+		cluster.Close()
+	}
 }
 
 func drainChannel[T any](ch chan T) []T {
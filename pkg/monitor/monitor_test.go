@@ -1,136 +1,127 @@
 package monitor
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
 )
 
-func TestIsActive(t *testing.T) {
-	now := time.Now()
-
-	tests := []struct {
-		name           string
-		lastWriteTime  time.Time
-		lastReadTime   time.Time
-		inactivityDays int
-		expected       bool
-	}{
-		{
-			name:           "both timestamps zero",
-			lastWriteTime:  time.Time{},
-			lastReadTime:   time.Time{},
-			inactivityDays: 7,
-			expected:       false,
-		},
-		{
-			name:           "both timestamps recent",
-			lastWriteTime:  now.Add(-24 * time.Hour),
-			lastReadTime:   now.Add(-48 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "write recent, read old",
-			lastWriteTime:  now.Add(-24 * time.Hour),
-			lastReadTime:   now.Add(-10 * 24 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "write old, read recent",
-			lastWriteTime:  now.Add(-10 * 24 * time.Hour),
-			lastReadTime:   now.Add(-24 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "both timestamps old",
-			lastWriteTime:  now.Add(-10 * 24 * time.Hour),
-			lastReadTime:   now.Add(-10 * 24 * time.Hour),
-			inactivityDays: 7,
-			expected:       false,
-		},
-		{
-			name:           "at exact boundary",
-			lastWriteTime:  now.Add(-7 * 24 * time.Hour),
-			lastReadTime:   now.Add(-7 * 24 * time.Hour),
-			inactivityDays: 7,
-			expected:       false,
-		},
-		{
-			name:           "just within boundary",
-			lastWriteTime:  now.Add(-7*24*time.Hour + time.Minute),
-			lastReadTime:   now.Add(-7*24*time.Hour + time.Minute),
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "zero write, recent read",
-			lastWriteTime:  time.Time{},
-			lastReadTime:   now.Add(-24 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "recent write, zero read",
-			lastWriteTime:  now.Add(-24 * time.Hour),
-			lastReadTime:   time.Time{},
-			inactivityDays: 7,
-			expected:       true,
-		},
-		{
-			name:           "old write, zero read",
-			lastWriteTime:  now.Add(-10 * 24 * time.Hour),
-			lastReadTime:   time.Time{},
-			inactivityDays: 7,
-			expected:       false,
-		},
-		{
-			name:           "zero write, old read",
-			lastWriteTime:  time.Time{},
-			lastReadTime:   now.Add(-10 * 24 * time.Hour),
-			inactivityDays: 7,
-			expected:       false,
-		},
-		{
-			name:           "longer inactivity period",
-			lastWriteTime:  now.Add(-14 * 24 * time.Hour),
-			lastReadTime:   now.Add(-14 * 24 * time.Hour),
-			inactivityDays: 30,
-			expected:       true,
+func TestReportTopicGroups(t *testing.T) {
+	m := &Monitor{clusters: []*Cluster{{Name: "cluster-a"}}}
+
+	_, err := m.reportTopicGroups("", "topic-a")
+	assert.ErrorIs(t, err, ErrNoScan)
+
+	m.lastScan = &report.Scan{
+		ScannedAt: time.Now(),
+		Topics: []*report.TopicActivityInfo{
+			{
+				Cluster:   "cluster-a",
+				TopicName: "topic-a",
+				ConsumerGroups: []report.ConsumerGroupInfo{
+					{GroupID: "group-a", TotalLag: 5},
+				},
+			},
 		},
-		{
-			name:           "zero inactivity period",
-			lastWriteTime:  now.Add(-1 * time.Hour),
-			lastReadTime:   now.Add(-1 * time.Hour),
-			inactivityDays: 0,
-			expected:       false,
+	}
+
+	// Cluster may be omitted when a single cluster is monitored.
+	got, err := m.reportTopicGroups("", "topic-a")
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `"GroupID": "group-a"`)
+	assert.Contains(t, string(got), `"TotalLag": 5`)
+
+	_, err = m.reportTopicGroups("cluster-a", "topic-b")
+	assert.ErrorIs(t, err, sarama.ErrUnknownTopicOrPartition)
+
+	_, err = m.reportTopicGroups("cluster-b", "topic-a")
+	assert.ErrorIs(t, err, ErrUnknownCluster)
+
+	m.clusters = append(m.clusters, &Cluster{Name: "cluster-b"})
+	_, err = m.reportTopicGroups("", "topic-a")
+	assert.ErrorIs(t, err, ErrClusterRequired)
+}
+
+func TestReportScanOfCluster(t *testing.T) {
+	m := &Monitor{clusters: []*Cluster{{Name: "cluster-a"}, {Name: "cluster-b"}}}
+	reporter := report.NewJson()
+
+	response := m.reportScan(reportQuery{cluster: "cluster-a", reporter: reporter})
+	assert.ErrorIs(t, response.err, ErrNoScan)
+
+	m.lastScan = &report.Scan{
+		ScannedAt: time.Now(),
+		Clusters: []report.ClusterInfo{
+			{Name: "cluster-a", TopicNumber: 1},
+			{Name: "cluster-b", TopicNumber: 1},
 		},
-		{
-			name:           "future timestamps",
-			lastWriteTime:  now.Add(24 * time.Hour),
-			lastReadTime:   now.Add(24 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "orders"},
+			{Cluster: "cluster-b", TopicName: "payments"},
+			{Cluster: "cluster-b", TopicName: "refunds", Status: report.StatusError, Error: "request timed out", ErrorClass: report.ErrorClassTimeout},
 		},
-		{
-			name:           "mixed activity timestamps",
-			lastWriteTime:  now.Add(-10 * 24 * time.Hour),
-			lastReadTime:   now.Add(-3 * 24 * time.Hour),
-			inactivityDays: 7,
-			expected:       true,
+	}
+
+	response = m.reportScan(reportQuery{reporter: reporter})
+	assert.NoError(t, response.err)
+	assert.Contains(t, string(response.report), `"TopicName": "orders"`)
+	assert.Contains(t, string(response.report), `"TopicName": "payments"`)
+	assert.Equal(t, report.ScanSummary{Topics: 3, Succeeded: 2, Failed: 1}, response.summary)
+
+	response = m.reportScan(reportQuery{cluster: "cluster-b", reporter: reporter})
+	assert.NoError(t, response.err)
+	assert.NotContains(t, string(response.report), `"TopicName": "orders"`)
+	assert.Contains(t, string(response.report), `"TopicName": "payments"`)
+	assert.Contains(t, string(response.report), `"ErrorClass": "timeout"`)
+	assert.Equal(t, report.ScanSummary{Topics: 2, Succeeded: 1, Failed: 1}, response.summary)
+
+	filter, err := NewTopicFilter([]string{"orders"}, nil, false)
+	assert.NoError(t, err)
+	response = m.reportScan(reportQuery{filter: filter, reporter: reporter})
+	assert.NoError(t, response.err)
+	assert.Equal(t, report.ScanSummary{Topics: 1, Succeeded: 1}, response.summary)
+
+	response = m.reportScan(reportQuery{cluster: "cluster-c", reporter: reporter})
+	assert.ErrorIs(t, response.err, ErrUnknownCluster)
+}
+
+func TestReportScanOrder(t *testing.T) {
+	m := &Monitor{clusters: []*Cluster{{Name: "cluster-a"}}}
+	m.lastScan = &report.Scan{
+		ScannedAt: time.Now(),
+		Clusters:  []report.ClusterInfo{{Name: "cluster-a", TopicNumber: 3}},
+		Topics: []*report.TopicActivityInfo{
+			{Cluster: "cluster-a", TopicName: "orders", Status: report.StatusActive, SizeBytes: 300},
+			{Cluster: "cluster-a", TopicName: "payments", Status: report.StatusIdle, SizeBytes: 100, ReclaimableBytes: 100},
+			{Cluster: "cluster-a", TopicName: "refunds", Status: report.StatusEmpty, SizeBytes: 200, ReclaimableBytes: 200},
 		},
 	}
+	topicNames := func(order string) []string {
+		compare, err := parseTopicOrder(order)
+		assert.NoError(t, err)
+		response := m.reportScan(reportQuery{order: compare, reporter: report.NewCsvReporter()})
+		assert.NoError(t, response.err)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := isActive(tt.lastWriteTime, tt.lastReadTime, tt.inactivityDays)
-			if got != tt.expected {
-				t.Errorf("isActive() = %v, want %v for %s", got, tt.expected, tt.name)
-				t.Errorf("  lastWriteTime: %v", tt.lastWriteTime)
-				t.Errorf("  lastReadTime: %v", tt.lastReadTime)
-				t.Errorf("  inactivityDays: %v", tt.inactivityDays)
+		var names []string
+		for _, line := range strings.Split(string(response.report), "\n")[1:] {
+			if fields := strings.Split(line, ","); len(fields) > 1 {
+				names = append(names, fields[1])
 			}
-		})
+		}
+		return names
 	}
+
+	assert.Equal(t, []string{"orders", "payments", "refunds"}, topicNames(""))
+	assert.Equal(t, []string{"refunds", "payments", "orders"}, topicNames(OrderReclaimable))
+	assert.Equal(t, []string{"orders", "refunds", "payments"}, topicNames(OrderSize))
+	// Sorting a report doesn't reorder the scan.
+	assert.Equal(t, "orders", m.lastScan.Topics[0].TopicName)
+
+	_, err := parseTopicOrder("name")
+	assert.ErrorIs(t, err, ErrUnknownOrder)
 }
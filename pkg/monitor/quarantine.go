@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	ErrSensitiveTopicConfig = errors.New("topic has sensitive configs which would be lost by altering its configs")
+)
+
+// QuarantineTopic lowers retention.ms of the topic to retention, retention which is already shorter is kept.
+// It returns the configs set by the quarantine and their original values, nil if they were not set on the topic.
+func (c *Cluster) QuarantineTopic(ctx context.Context, topicName string, retention time.Duration) (map[string]string, map[string]*string, error) {
+	var (
+		configs  = make(map[string]string)
+		original = make(map[string]*string)
+	)
+	err := c.alterTopicConfigs(ctx, topicName, func(entries []sarama.ConfigEntry, overrides map[string]string) error {
+		retentionMs := retention.Milliseconds()
+		for _, entry := range entries {
+			if entry.Name != configRetentionMs {
+				continue
+			}
+			current, err := strconv.ParseInt(entry.Value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", configRetentionMs, err)
+			}
+			// -1 means unlimited retention.
+			if current >= 0 && current < retentionMs {
+				retentionMs = current
+			}
+		}
+
+		if value, ok := overrides[configRetentionMs]; ok {
+			original[configRetentionMs] = &value
+		} else {
+			original[configRetentionMs] = nil
+		}
+		configs[configRetentionMs] = strconv.FormatInt(retentionMs, 10)
+		overrides[configRetentionMs] = configs[configRetentionMs]
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to quarantine topic %s: %w", topicName, err)
+	}
+	return configs, original, nil
+}
+
+// RevertTopic restores the original values of configs altered by QuarantineTopic, configs which were not set
+// on the topic are removed. Other configs changed during the quarantine are kept.
+func (c *Cluster) RevertTopic(ctx context.Context, topicName string, original map[string]*string) error {
+	err := c.alterTopicConfigs(ctx, topicName, func(entries []sarama.ConfigEntry, overrides map[string]string) error {
+		for name, value := range original {
+			if value == nil {
+				delete(overrides, name)
+			} else {
+				overrides[name] = *value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert topic %s: %w", topicName, err)
+	}
+	return nil
+}
+
+// alterTopicConfigs passes configs of the topic and the ones set on the topic itself to alter and sets the configs
+// altered on the topic. Brokers of Kafka 2.3 or newer get only the altered configs by IncrementalAlterConfigs.
+// Older brokers fall back to AlterConfigs which resets configs missing from the request to defaults, so all configs
+// set on the topic are sent and topics with sensitive configs, whose values are not described, are refused.
+func (c *Cluster) alterTopicConfigs(ctx context.Context, topicName string, alter func(entries []sarama.ConfigEntry, overrides map[string]string) error) error {
+	entries, err := callWithContext(ctx, func() ([]sarama.ConfigEntry, error) {
+		return c.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topicName})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe configs: %w", err)
+	}
+	if !c.incrementalAlterConfigs {
+		for _, entry := range entries {
+			if entry.Sensitive && entry.Source == sarama.SourceTopic {
+				return fmt.Errorf("%w: %s", ErrSensitiveTopicConfig, entry.Name)
+			}
+		}
+	}
+
+	overrides := topicConfigOverrides(entries)
+	altered := topicConfigOverrides(entries)
+	if err := alter(entries, altered); err != nil {
+		return err
+	}
+	_, err = callWithContext(ctx, func() (struct{}, error) {
+		if c.incrementalAlterConfigs {
+			return struct{}{}, c.admin.IncrementalAlterConfig(sarama.TopicResource, topicName, incrementalConfigEntries(overrides, altered), false)
+		}
+		alterEntries := make(map[string]*string, len(altered))
+		for name, value := range altered {
+			alterEntries[name] = &value
+		}
+		return struct{}{}, c.admin.AlterConfig(sarama.TopicResource, topicName, alterEntries, false)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to alter configs: %w", err)
+	}
+	return nil
+}
+
+// incrementalConfigEntries returns operations turning configs set on the topic into the altered ones,
+// configs which were not altered are left out.
+func incrementalConfigEntries(overrides, altered map[string]string) map[string]sarama.IncrementalAlterConfigsEntry {
+	entries := make(map[string]sarama.IncrementalAlterConfigsEntry)
+	for name, value := range altered {
+		if current, ok := overrides[name]; !ok || current != value {
+			entries[name] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &value}
+		}
+	}
+	for name := range overrides {
+		if _, ok := altered[name]; !ok {
+			entries[name] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationDelete}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConfigAdmin holds configs of a single topic, other calls of the admin client are not implemented.
+type fakeConfigAdmin struct {
+	sarama.ClusterAdmin
+	entries     []sarama.ConfigEntry
+	altered     map[string]*string
+	incremental map[string]sarama.IncrementalAlterConfigsEntry
+}
+
+func (a *fakeConfigAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return a.entries, nil
+}
+
+func (a *fakeConfigAdmin) AlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error {
+	a.altered = entries
+	return nil
+}
+
+func (a *fakeConfigAdmin) IncrementalAlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	a.incremental = entries
+	return nil
+}
+
+func TestQuarantineTopic(t *testing.T) {
+	admin := &fakeConfigAdmin{entries: []sarama.ConfigEntry{
+		{Name: configRetentionMs, Value: "604800000", Source: sarama.SourceDefault, Default: true},
+		{Name: configCleanupPolicy, Value: "delete", Source: sarama.SourceTopic},
+	}}
+	// Brokers older than Kafka 2.3 get all configs set on the topic.
+	cluster := &Cluster{Name: "cluster-a", admin: admin}
+
+	configs, original, err := cluster.QuarantineTopic(context.Background(), "orders", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{configRetentionMs: "3600000"}, configs)
+	assert.Equal(t, map[string]*string{configRetentionMs: nil}, original)
+	// Configs set on the topic are kept, AlterConfigs would reset them otherwise.
+	require.Len(t, admin.altered, 2)
+	assert.Equal(t, "3600000", *admin.altered[configRetentionMs])
+	assert.Equal(t, "delete", *admin.altered[configCleanupPolicy])
+
+	// Retention shorter than the quarantine retention is kept.
+	admin.entries[0] = sarama.ConfigEntry{Name: configRetentionMs, Value: "60000", Source: sarama.SourceTopic}
+	configs, original, err = cluster.QuarantineTopic(context.Background(), "orders", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "60000", configs[configRetentionMs])
+	assert.Equal(t, "60000", *original[configRetentionMs])
+
+	admin.entries = append(admin.entries, sarama.ConfigEntry{Name: "sasl.jaas.config", Source: sarama.SourceTopic, Sensitive: true})
+	_, _, err = cluster.QuarantineTopic(context.Background(), "orders", time.Hour)
+	assert.ErrorIs(t, err, ErrSensitiveTopicConfig)
+}
+
+func TestRevertTopic(t *testing.T) {
+	admin := &fakeConfigAdmin{entries: []sarama.ConfigEntry{
+		{Name: configRetentionMs, Value: "3600000", Source: sarama.SourceTopic},
+		{Name: configMinInSyncReplicas, Value: "2", Source: sarama.SourceTopic},
+	}}
+	cluster := &Cluster{Name: "cluster-a", admin: admin}
+
+	require.NoError(t, cluster.RevertTopic(context.Background(), "orders", map[string]*string{configRetentionMs: nil}))
+	require.Len(t, admin.altered, 1)
+	assert.Equal(t, "2", *admin.altered[configMinInSyncReplicas])
+
+	retention := "604800000"
+	require.NoError(t, cluster.RevertTopic(context.Background(), "orders", map[string]*string{configRetentionMs: &retention}))
+	assert.Equal(t, retention, *admin.altered[configRetentionMs])
+}
+
+func TestQuarantineTopicIncrementally(t *testing.T) {
+	admin := &fakeConfigAdmin{entries: []sarama.ConfigEntry{
+		{Name: configRetentionMs, Value: "604800000", Source: sarama.SourceDefault, Default: true},
+		{Name: configCleanupPolicy, Value: "delete", Source: sarama.SourceTopic},
+		{Name: "sasl.jaas.config", Source: sarama.SourceTopic, Sensitive: true},
+	}}
+	cluster := &Cluster{Name: "cluster-a", admin: admin, incrementalAlterConfigs: true}
+
+	// Only the altered config is sent, so sensitive configs are kept rather than refused.
+	configs, original, err := cluster.QuarantineTopic(context.Background(), "orders", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{configRetentionMs: "3600000"}, configs)
+	assert.Equal(t, map[string]*string{configRetentionMs: nil}, original)
+	assert.Nil(t, admin.altered)
+	require.Len(t, admin.incremental, 1)
+	assert.Equal(t, sarama.IncrementalAlterConfigsOperationSet, admin.incremental[configRetentionMs].Operation)
+	assert.Equal(t, "3600000", *admin.incremental[configRetentionMs].Value)
+
+	admin.entries[0] = sarama.ConfigEntry{Name: configRetentionMs, Value: "3600000", Source: sarama.SourceTopic}
+	require.NoError(t, cluster.RevertTopic(context.Background(), "orders", original))
+	assert.Equal(t, map[string]sarama.IncrementalAlterConfigsEntry{
+		configRetentionMs: {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+	}, admin.incremental)
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,7 +16,10 @@ func NewCsvReporter() *CsvReporter {
 	return &CsvReporter{}
 }
 
-func (r *CsvReporter) Report(topicActivityInfos []*TopicActivityInfo) ([]byte, error) {
+// Report writes one row per topic partition, topic level columns are repeated on every partition row.
+// Topics without partitions are written as a single row with empty partition columns.
+// The summary of topic checks of the scan is repeated in the last columns of every row.
+func (r *CsvReporter) Report(scan *Scan) ([]byte, error) {
 	// Create a buffer to write to
 	var buf bytes.Buffer
 
@@ -22,7 +27,12 @@ func (r *CsvReporter) Report(topicActivityInfos []*TopicActivityInfo) ([]byte, e
 	csvWriter := csv.NewWriter(&buf)
 
 	// Write the header row
-	header := []string{"Topic", "LastWriteTime", "LastReadTime", "PartitionNumber", "Active"}
+	header := []string{
+		"Cluster", "Topic", "LastWriteTime", "LastReadTime", "PartitionNumber", "Status", "InactivityRule", "WriteInactivity", "ReadInactivity", "LastActiveTime", "FirstSeenTime", "CheckDuration", "Error", "ErrorClass",
+		"RetentionMs", "RetentionBytes", "CleanupPolicy", "ReplicationFactor", "MinInSyncReplicas", "SizeBytes", "StorageCost", "ReclaimableBytes",
+		"Partition", "OldestOffset", "NewestOffset", "PartitionLastWriteTime", "MessageCount", "PartitionSizeBytes", "PartitionEmpty", "SearchDistance", "CommittedOffsets", "Lags",
+		"TopicsTotal", "TopicsSucceeded", "TopicsFailed",
+	}
 	if err := csvWriter.Write(header); err != nil {
 		return nil, fmt.Errorf("error writing CSV header: %w", err)
 	}
@@ -30,18 +40,66 @@ func (r *CsvReporter) Report(topicActivityInfos []*TopicActivityInfo) ([]byte, e
 	// Use RFC3339 format for timestamps (ISO 8601)
 	timeFormat := time.RFC3339
 
+	summaryColumns := []string{
+		strconv.Itoa(scan.Summary.Topics),
+		strconv.Itoa(scan.Summary.Succeeded),
+		strconv.Itoa(scan.Summary.Failed),
+	}
+
 	// Write the data rows
-	for _, activity := range topicActivityInfos {
-		row := []string{
+	for _, activity := range scan.Topics {
+		topicColumns := []string{
+			activity.Cluster,
 			activity.TopicName,
 			activity.LastWriteTime.Format(timeFormat),
 			activity.LastReadTime.Format(timeFormat),
 			strconv.Itoa(activity.PartitionNumber),
-			strconv.FormatBool(activity.Active),
+			string(activity.Status),
+			activity.InactivityRule,
+			activity.WriteInactivity.String(),
+			activity.ReadInactivity.String(),
+			activity.LastActiveTime.Format(timeFormat),
+			activity.FirstSeenTime.Format(timeFormat),
+			activity.CheckDuration.String(),
+			activity.Error,
+			string(activity.ErrorClass),
+			strconv.FormatInt(activity.RetentionMs, 10),
+			strconv.FormatInt(activity.RetentionBytes, 10),
+			activity.CleanupPolicy,
+			strconv.Itoa(activity.ReplicationFactor),
+			strconv.Itoa(activity.MinInSyncReplicas),
+			strconv.FormatInt(activity.SizeBytes, 10),
+			strconv.FormatFloat(activity.StorageCost, 'f', 2, 64),
+			strconv.FormatInt(activity.ReclaimableBytes, 10),
 		}
 
-		if err := csvWriter.Write(row); err != nil {
-			return nil, fmt.Errorf("error writing CSV row: %w", err)
+		if len(activity.Partitions) == 0 {
+			row := append(topicColumns, "", "", "", "", "", "", "", "", "", "")
+			row = append(row, summaryColumns...)
+			if err := csvWriter.Write(row); err != nil {
+				return nil, fmt.Errorf("error writing CSV row: %w", err)
+			}
+			continue
+		}
+
+		for _, partition := range activity.Partitions {
+			row := append(topicColumns[:len(topicColumns):len(topicColumns)],
+				strconv.FormatInt(int64(partition.Partition), 10),
+				strconv.FormatInt(partition.OldestOffset, 10),
+				strconv.FormatInt(partition.NewestOffset, 10),
+				partition.LastWriteTime.Format(timeFormat),
+				strconv.FormatInt(partition.MessageCount, 10),
+				strconv.FormatInt(partition.SizeBytes, 10),
+				strconv.FormatBool(partition.Empty),
+				strconv.FormatInt(partition.SearchDistance, 10),
+				formatGroupOffsets(partition.CommittedOffsets),
+				formatGroupOffsets(partition.Lags()),
+			)
+			row = append(row, summaryColumns...)
+
+			if err := csvWriter.Write(row); err != nil {
+				return nil, fmt.Errorf("error writing CSV row: %w", err)
+			}
 		}
 	}
 
@@ -56,3 +114,18 @@ func (r *CsvReporter) Report(topicActivityInfos []*TopicActivityInfo) ([]byte, e
 	// Return the buffer's bytes
 	return buf.Bytes(), nil
 }
+
+// formatGroupOffsets formats per-group values as group=value pairs separated by semicolon, sorted by group.
+func formatGroupOffsets(groupOffsets map[string]int64) string {
+	groups := make([]string, 0, len(groupOffsets))
+	for group := range groupOffsets {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	pairs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", group, groupOffsets[group]))
+	}
+	return strings.Join(pairs, ";")
+}
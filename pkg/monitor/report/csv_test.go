@@ -0,0 +1,127 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporter_Report(t *testing.T) {
+	// Create a reporter instance
+	r := &CsvReporter{}
+
+	// Define test data
+	topicActivityInfos := []*TopicActivityInfo{
+		{
+			Cluster:         "cluster-a",
+			TopicName:       "topic-a",
+			LastWriteTime:   time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+			LastReadTime:    time.Date(2023, 10, 1, 12, 5, 0, 0, time.UTC),
+			PartitionNumber: 0,
+			Status:          StatusIdle,
+			InactivityRule:  "default",
+			WriteInactivity: 7 * 24 * time.Hour,
+			ReadInactivity:  7 * 24 * time.Hour,
+		},
+		{
+			Cluster:           "cluster-b",
+			TopicName:         "topic-b",
+			LastWriteTime:     time.Date(2023, 10, 1, 13, 0, 0, 0, time.UTC),
+			LastReadTime:      time.Date(2023, 10, 1, 13, 5, 0, 0, time.UTC),
+			PartitionNumber:   2,
+			Status:            StatusWriteOnly,
+			Active:            true,
+			InactivityRule:    "events",
+			WriteInactivity:   time.Minute,
+			ReadInactivity:    time.Hour,
+			LastActiveTime:    time.Date(2023, 10, 1, 14, 0, 0, 0, time.UTC),
+			FirstSeenTime:     time.Date(2023, 9, 1, 14, 0, 0, 0, time.UTC),
+			CheckDuration:     1500 * time.Millisecond,
+			RetentionMs:       604800000,
+			RetentionBytes:    -1,
+			CleanupPolicy:     "compact,delete",
+			ReplicationFactor: 3,
+			MinInSyncReplicas: 2,
+			SizeBytes:         4096,
+			StorageCost:       0.25,
+			Partitions: []PartitionActivityInfo{
+				{
+					Partition:        0,
+					OldestOffset:     0,
+					NewestOffset:     10,
+					LastWriteTime:    time.Date(2023, 10, 1, 13, 0, 0, 0, time.UTC),
+					MessageCount:     10,
+					SizeBytes:        4000,
+					SearchDistance:   1,
+					CommittedOffsets: map[string]int64{"group-b": 10, "group-a": 5},
+				},
+				{
+					Partition:      1,
+					OldestOffset:   3,
+					NewestOffset:   4,
+					LastWriteTime:  time.Date(2023, 9, 1, 13, 0, 0, 0, time.UTC),
+					MessageCount:   1,
+					SizeBytes:      96,
+					SearchDistance: 3,
+				},
+				{
+					Partition:    2,
+					OldestOffset: 7,
+					NewestOffset: 7,
+					Empty:        true,
+				},
+			},
+		},
+		{
+			Cluster:    "cluster-b",
+			TopicName:  "topic-c",
+			Status:     StatusError,
+			Error:      "failed to get partitions for topic topic-c: kafka server: Topic authorization failed",
+			ErrorClass: ErrorClassAuthorization,
+		},
+	}
+
+	// Call the Report method
+	result, err := r.Report(&Scan{ScannedAt: time.Now(), Topics: topicActivityInfos, Summary: Summarize(topicActivityInfos)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Parse the result as CSV
+	reader := csv.NewReader(bytes.NewReader(result))
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+
+	// Verify the header
+	expectedHeader := []string{
+		"Cluster", "Topic", "LastWriteTime", "LastReadTime", "PartitionNumber", "Status", "InactivityRule", "WriteInactivity", "ReadInactivity", "LastActiveTime", "FirstSeenTime", "CheckDuration", "Error", "ErrorClass",
+		"RetentionMs", "RetentionBytes", "CleanupPolicy", "ReplicationFactor", "MinInSyncReplicas", "SizeBytes", "StorageCost", "ReclaimableBytes",
+		"Partition", "OldestOffset", "NewestOffset", "PartitionLastWriteTime", "MessageCount", "PartitionSizeBytes", "PartitionEmpty", "SearchDistance", "CommittedOffsets", "Lags",
+		"TopicsTotal", "TopicsSucceeded", "TopicsFailed",
+	}
+	if len(records) < 1 || !assert.Equal(t, records[0], expectedHeader) {
+		t.Errorf("expected header %v, got %v", expectedHeader, records[0])
+	}
+
+	// Verify the data rows
+	expectedRows := [][]string{
+		{"cluster-a", "topic-a", "2023-10-01T12:00:00Z", "2023-10-01T12:05:00Z", "0", "idle", "default", "168h0m0s", "168h0m0s", "0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z", "0s", "", "", "0", "0", "", "0", "0", "0", "0.00", "0", "", "", "", "", "", "", "", "", "", "", "3", "2", "1"},
+		{"cluster-b", "topic-b", "2023-10-01T13:00:00Z", "2023-10-01T13:05:00Z", "2", "write-only", "events", "1m0s", "1h0m0s", "2023-10-01T14:00:00Z", "2023-09-01T14:00:00Z", "1.5s", "", "", "604800000", "-1", "compact,delete", "3", "2", "4096", "0.25", "0", "0", "0", "10", "2023-10-01T13:00:00Z", "10", "4000", "false", "1", "group-a=5;group-b=10", "group-a=5;group-b=0", "3", "2", "1"},
+		{"cluster-b", "topic-b", "2023-10-01T13:00:00Z", "2023-10-01T13:05:00Z", "2", "write-only", "events", "1m0s", "1h0m0s", "2023-10-01T14:00:00Z", "2023-09-01T14:00:00Z", "1.5s", "", "", "604800000", "-1", "compact,delete", "3", "2", "4096", "0.25", "0", "1", "3", "4", "2023-09-01T13:00:00Z", "1", "96", "false", "3", "", "", "3", "2", "1"},
+		{"cluster-b", "topic-b", "2023-10-01T13:00:00Z", "2023-10-01T13:05:00Z", "2", "write-only", "events", "1m0s", "1h0m0s", "2023-10-01T14:00:00Z", "2023-09-01T14:00:00Z", "1.5s", "", "", "604800000", "-1", "compact,delete", "3", "2", "4096", "0.25", "0", "2", "7", "7", "0001-01-01T00:00:00Z", "0", "0", "true", "0", "", "", "3", "2", "1"},
+		{"cluster-b", "topic-c", "0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z", "0", "error", "", "0s", "0s", "0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z", "0s", "failed to get partitions for topic topic-c: kafka server: Topic authorization failed", "authorization", "0", "0", "", "0", "0", "0", "0.00", "0", "", "", "", "", "", "", "", "", "", "", "3", "2", "1"},
+	}
+	if !assert.Len(t, records, len(expectedRows)+1) {
+		return
+	}
+	for i, expectedRow := range expectedRows {
+		if !assert.Equal(t, records[i+1], expectedRow) {
+			t.Errorf("expected row %v, got %v", expectedRow, records[i+1])
+		}
+	}
+}
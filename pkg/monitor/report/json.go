@@ -11,8 +11,8 @@ func NewJson() *Json {
 	return &Json{}
 }
 
-func (r *Json) Report(topicActivityInfos []*TopicActivityInfo) ([]byte, error) {
-	data, err := json.MarshalIndent(topicActivityInfos, "", "  ")
+func (r *Json) Report(scan *Scan) ([]byte, error) {
+	data, err := json.MarshalIndent(scan, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling JSON: %w", err)
 	}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrometheusContentType is the content type of Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Prometheus writes scan in Prometheus text exposition format.
+type Prometheus struct{}
+
+func NewPrometheus() *Prometheus {
+	return &Prometheus{}
+}
+
+func (r *Prometheus) Report(scan *Scan) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeMetric(&buf, "kafka_monitor_scans_total", "counter", "Number of completed scans.")
+	writeSample(&buf, "kafka_monitor_scans_total", nil, float64(scan.Counters.Scans))
+	writeMetric(&buf, "kafka_monitor_scan_errors_total", "counter", "Number of scans failed to list topics.")
+	writeSample(&buf, "kafka_monitor_scan_errors_total", nil, float64(scan.Counters.ScanErrors))
+	writeMetric(&buf, "kafka_monitor_topic_errors_total", "counter", "Number of failed topic checks.")
+	writeSample(&buf, "kafka_monitor_topic_errors_total", nil, float64(scan.Counters.TopicErrors))
+	writeMetric(&buf, "kafka_monitor_last_scan_timestamp_seconds", "gauge", "Time when the latest scan started.")
+	writeSample(&buf, "kafka_monitor_last_scan_timestamp_seconds", nil, unixSeconds(scan.ScannedAt))
+	writeMetric(&buf, "kafka_monitor_last_scan_duration_seconds", "gauge", "Time it took to complete the latest scan.")
+	writeSample(&buf, "kafka_monitor_last_scan_duration_seconds", nil, scan.Duration.Seconds())
+
+	writeMetric(&buf, "kafka_monitor_last_scan_topics", "gauge", "Number of topics checked by the latest scan by outcome of the check.")
+	writeSample(&buf, "kafka_monitor_last_scan_topics", []string{"result", "succeeded"}, float64(scan.Summary.Succeeded))
+	writeSample(&buf, "kafka_monitor_last_scan_topics", []string{"result", "failed"}, float64(scan.Summary.Failed))
+
+	writeMetric(&buf, "kafka_monitor_cluster_up", "gauge", "Whether topics of the cluster were listed by the latest scan.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_up", []string{"cluster", cluster.Name}, boolValue(cluster.Error == ""))
+	}
+	writeMetric(&buf, "kafka_monitor_cluster_topics", "gauge", "Number of topics checked in the cluster by the latest scan.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_topics", []string{"cluster", cluster.Name}, float64(cluster.TopicNumber))
+	}
+	writeMetric(&buf, "kafka_monitor_cluster_failed_topics", "gauge", "Number of topics of the cluster failed to be checked by the latest scan.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_failed_topics", []string{"cluster", cluster.Name}, float64(cluster.FailedTopics))
+	}
+	writeMetric(&buf, "kafka_monitor_cluster_size_bytes", "gauge", "Size of topics of the cluster checked by the latest scan on disks of the brokers.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_size_bytes", []string{"cluster", cluster.Name}, float64(cluster.SizeBytes))
+	}
+	writeMetric(&buf, "kafka_monitor_cluster_reclaimable_bytes", "gauge", "Size of idle and empty topics of the cluster checked by the latest scan.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_reclaimable_bytes", []string{"cluster", cluster.Name}, float64(cluster.ReclaimableBytes))
+	}
+	writeMetric(&buf, "kafka_monitor_cluster_scan_duration_seconds", "gauge", "Time it took to scan the cluster by the latest scan.")
+	for _, cluster := range scan.Clusters {
+		writeSample(&buf, "kafka_monitor_cluster_scan_duration_seconds", []string{"cluster", cluster.Name}, cluster.Duration.Seconds())
+	}
+
+	topicGauges := []struct {
+		name  string
+		help  string
+		value func(*TopicActivityInfo) float64
+	}{
+		{"kafka_topic_last_write_timestamp_seconds", "Time when last message was written to any partition.", func(info *TopicActivityInfo) float64 { return unixSeconds(info.LastWriteTime) }},
+		{"kafka_topic_last_read_timestamp_seconds", "Time when message was consumed by any consumer group.", func(info *TopicActivityInfo) float64 { return unixSeconds(info.LastReadTime) }},
+		{"kafka_topic_active", "Whether the topic has recent activity.", func(info *TopicActivityInfo) float64 { return boolValue(info.Active) }},
+		{"kafka_topic_first_seen_timestamp_seconds", "Time of the first scan which found the topic.", func(info *TopicActivityInfo) float64 { return unixSeconds(info.FirstSeenTime) }},
+		{"kafka_topic_partitions", "Number of partitions in topic.", func(info *TopicActivityInfo) float64 { return float64(info.PartitionNumber) }},
+		{"kafka_topic_check_duration_seconds", "Time it took to check the topic.", func(info *TopicActivityInfo) float64 { return info.CheckDuration.Seconds() }},
+		{"kafka_topic_retention_ms", "Value of retention.ms of the topic, -1 for unlimited.", func(info *TopicActivityInfo) float64 { return float64(info.RetentionMs) }},
+		{"kafka_topic_retention_bytes", "Value of retention.bytes of the topic, -1 for unlimited.", func(info *TopicActivityInfo) float64 { return float64(info.RetentionBytes) }},
+		{"kafka_topic_replication_factor", "Number of replicas of partitions of the topic.", func(info *TopicActivityInfo) float64 { return float64(info.ReplicationFactor) }},
+		{"kafka_topic_min_insync_replicas", "Value of min.insync.replicas of the topic.", func(info *TopicActivityInfo) float64 { return float64(info.MinInSyncReplicas) }},
+		{"kafka_topic_size_bytes", "Size of all replicas of the topic on disks of the brokers.", func(info *TopicActivityInfo) float64 { return float64(info.SizeBytes) }},
+		{"kafka_topic_storage_cost", "Cost of storing the topic, size in GB times storage cost per GB of the cluster.", func(info *TopicActivityInfo) float64 { return info.StorageCost }},
+		{"kafka_topic_reclaimable_bytes", "Size of the topic if it is idle or empty.", func(info *TopicActivityInfo) float64 { return float64(info.ReclaimableBytes) }},
+	}
+	for _, gauge := range topicGauges {
+		writeMetric(&buf, gauge.name, "gauge", gauge.help)
+		for _, info := range scan.Topics {
+			writeSample(&buf, gauge.name, topicLabels(info), gauge.value(info))
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_status", "gauge", "Status of the topic computed from recency of writes and reads, 1 for the current status.")
+	for _, info := range scan.Topics {
+		for _, status := range topicStatuses {
+			writeSample(&buf, "kafka_topic_status", append(topicLabels(info), "status", string(status)), boolValue(info.Status == status))
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_cleanup_policy", "gauge", "Value of cleanup.policy of the topic, 1 for the current policy.")
+	for _, info := range scan.Topics {
+		if info.CleanupPolicy != "" {
+			writeSample(&buf, "kafka_topic_cleanup_policy", append(topicLabels(info), "policy", info.CleanupPolicy), 1)
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_check_error", "gauge", "Whether the check of the topic failed, by class of the error.")
+	for _, info := range scan.Topics {
+		if info.Failed() {
+			writeSample(&buf, "kafka_topic_check_error", append(topicLabels(info), "class", string(info.ErrorClass)), 1)
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_write_inactivity_threshold_seconds", "gauge", "Time without writes after which writes are not recent, by the rule which classified the topic.")
+	for _, info := range scan.Topics {
+		writeSample(&buf, "kafka_topic_write_inactivity_threshold_seconds", append(topicLabels(info), "rule", info.InactivityRule), info.WriteInactivity.Seconds())
+	}
+	writeMetric(&buf, "kafka_topic_read_inactivity_threshold_seconds", "gauge", "Time without reads after which reads are not recent, by the rule which classified the topic.")
+	for _, info := range scan.Topics {
+		writeSample(&buf, "kafka_topic_read_inactivity_threshold_seconds", append(topicLabels(info), "rule", info.InactivityRule), info.ReadInactivity.Seconds())
+	}
+
+	writeMetric(&buf, "kafka_topic_partition_oldest_offset", "gauge", "Oldest available offset in partition.")
+	for _, info := range scan.Topics {
+		for _, partition := range info.Partitions {
+			writeSample(&buf, "kafka_topic_partition_oldest_offset", partitionLabels(info, partition), float64(partition.OldestOffset))
+		}
+	}
+	writeMetric(&buf, "kafka_topic_partition_newest_offset", "gauge", "Offset of the next message to be written to partition.")
+	for _, info := range scan.Topics {
+		for _, partition := range info.Partitions {
+			writeSample(&buf, "kafka_topic_partition_newest_offset", partitionLabels(info, partition), float64(partition.NewestOffset))
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_partition_size_bytes", "gauge", "Size of all replicas of partition on disks of the brokers.")
+	for _, info := range scan.Topics {
+		for _, partition := range info.Partitions {
+			writeSample(&buf, "kafka_topic_partition_size_bytes", partitionLabels(info, partition), float64(partition.SizeBytes))
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_partition_last_record_search_distance", "gauge", "Number of offsets walked back from the newest offset to find the last record of partition.")
+	for _, info := range scan.Topics {
+		for _, partition := range info.Partitions {
+			writeSample(&buf, "kafka_topic_partition_last_record_search_distance", partitionLabels(info, partition), float64(partition.SearchDistance))
+		}
+	}
+
+	writeMetric(&buf, "kafka_topic_consumer_group_lag", "gauge", "Number of messages not yet consumed by consumer group.")
+	for _, info := range scan.Topics {
+		for _, group := range info.ConsumerGroups {
+			writeSample(&buf, "kafka_topic_consumer_group_lag", append(topicLabels(info), "group", group.GroupID), float64(group.TotalLag))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// topicStatuses are all statuses reported by kafka_topic_status, so every topic has a sample for each of them.
+var topicStatuses = []TopicStatus{StatusActive, StatusWriteOnly, StatusReadOnly, StatusIdle, StatusEmpty, StatusUnknown, StatusError, StatusTimeout}
+
+func writeMetric(buf *bytes.Buffer, name, metricType, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+}
+
+// writeSample writes a sample, labels are name and value pairs.
+func writeSample(buf *bytes.Buffer, name string, labels []string, value float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	buf.WriteByte('\n')
+}
+
+func topicLabels(info *TopicActivityInfo) []string {
+	return []string{"cluster", info.Cluster, "topic", info.TopicName}
+}
+
+func partitionLabels(info *TopicActivityInfo, partition PartitionActivityInfo) []string {
+	return append(topicLabels(info), "partition", strconv.FormatInt(int64(partition.Partition), 10))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+// unixSeconds returns t as Unix time in seconds, zero time is reported as 0.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheus_Report(t *testing.T) {
+	scan := &Scan{
+		ScannedAt: time.Unix(1696161600, 0),
+		Duration:  2500 * time.Millisecond,
+		Counters:  ScanCounters{Scans: 3, ScanErrors: 1, TopicErrors: 2},
+		Clusters: []ClusterInfo{
+			{Name: "cluster-a", TopicNumber: 2, FailedTopics: 1, SizeBytes: 2048, ReclaimableBytes: 2048},
+			{Name: "cluster-b", Error: "connection refused"},
+		},
+		Topics: []*TopicActivityInfo{
+			{
+				Cluster:           "cluster-a",
+				TopicName:         `topic-"a"`,
+				LastWriteTime:     time.Unix(1696160000, 0),
+				PartitionNumber:   1,
+				Status:            StatusWriteOnly,
+				Active:            true,
+				InactivityRule:    "events",
+				WriteInactivity:   90 * time.Second,
+				ReadInactivity:    time.Hour,
+				RetentionMs:       -1,
+				CleanupPolicy:     "compact",
+				ReplicationFactor: 3,
+				SizeBytes:         2048,
+				ReclaimableBytes:  2048,
+				Partitions: []PartitionActivityInfo{
+					{Partition: 0, OldestOffset: 5, NewestOffset: 10, SearchDistance: 2, SizeBytes: 1024},
+				},
+				ConsumerGroups: []ConsumerGroupInfo{
+					{GroupID: "group-a", TotalLag: 4},
+				},
+			},
+			{
+				Cluster:    "cluster-a",
+				TopicName:  "topic-b",
+				Status:     StatusError,
+				Error:      "request timed out",
+				ErrorClass: ErrorClassTimeout,
+			},
+		},
+		Summary: ScanSummary{Topics: 2, Succeeded: 1, Failed: 1},
+	}
+
+	result, err := NewPrometheus().Report(scan)
+	assert.NoError(t, err)
+
+	expected := []string{
+		"# TYPE kafka_monitor_scans_total counter\nkafka_monitor_scans_total 3\n",
+		"kafka_monitor_scan_errors_total 1\n",
+		"kafka_monitor_topic_errors_total 2\n",
+		"kafka_monitor_last_scan_timestamp_seconds 1.6961616e+09\n",
+		"kafka_monitor_last_scan_duration_seconds 2.5\n",
+		"kafka_monitor_cluster_up{cluster=\"cluster-a\"} 1\nkafka_monitor_cluster_up{cluster=\"cluster-b\"} 0\n",
+		"kafka_monitor_last_scan_topics{result=\"succeeded\"} 1\nkafka_monitor_last_scan_topics{result=\"failed\"} 1\n",
+		"kafka_monitor_cluster_topics{cluster=\"cluster-a\"} 2\n",
+		"kafka_monitor_cluster_failed_topics{cluster=\"cluster-a\"} 1\n",
+		"# TYPE kafka_topic_last_write_timestamp_seconds gauge\nkafka_topic_last_write_timestamp_seconds{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 1.69616e+09\n",
+		"kafka_topic_last_read_timestamp_seconds{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 0\n",
+		"kafka_topic_active{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 1\n",
+		"kafka_topic_status{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",status=\"active\"} 0\n",
+		"kafka_topic_status{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",status=\"write-only\"} 1\n",
+		"kafka_topic_status{cluster=\"cluster-a\",topic=\"topic-b\",status=\"error\"} 1\n",
+		"# TYPE kafka_topic_check_error gauge\nkafka_topic_check_error{cluster=\"cluster-a\",topic=\"topic-b\",class=\"timeout\"} 1\n",
+		"kafka_topic_write_inactivity_threshold_seconds{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",rule=\"events\"} 90\n",
+		"kafka_topic_read_inactivity_threshold_seconds{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",rule=\"events\"} 3600\n",
+		"kafka_topic_partitions{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 1\n",
+		"kafka_topic_partition_oldest_offset{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",partition=\"0\"} 5\n",
+		"kafka_topic_partition_newest_offset{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",partition=\"0\"} 10\n",
+		"kafka_topic_retention_ms{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} -1\n",
+		"kafka_topic_replication_factor{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 3\n",
+		"kafka_topic_size_bytes{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 2048\n",
+		"kafka_topic_cleanup_policy{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",policy=\"compact\"} 1\n",
+		"kafka_topic_partition_last_record_search_distance{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",partition=\"0\"} 2\n",
+		"kafka_monitor_cluster_reclaimable_bytes{cluster=\"cluster-a\"} 2048\n",
+		"kafka_topic_reclaimable_bytes{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\"} 2048\n",
+		"kafka_topic_partition_size_bytes{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",partition=\"0\"} 1024\n",
+		"kafka_topic_consumer_group_lag{cluster=\"cluster-a\",topic=\"topic-\\\"a\\\"\",group=\"group-a\"} 4\n",
+	}
+	for _, line := range expected {
+		assert.Contains(t, string(result), line)
+	}
+}
@@ -2,11 +2,190 @@ package report
 
 import "time"
 
+// DefaultCluster is the name of the cluster configured without a name, topics without a cluster belong to it.
+const DefaultCluster = "default"
+
+// Scan contains activity of all topics collected during a single scan of the clusters.
+type Scan struct {
+	ScannedAt time.Time            `json:"scanned_at"` // Time when the scan started.
+	Duration  time.Duration        `json:"duration"`   // Time it took to scan all topics.
+	Clusters  []ClusterInfo        `json:"clusters"`   // Scanned clusters.
+	Topics    []*TopicActivityInfo `json:"topics"`     // Activity of scanned topics of all clusters.
+	Counters  ScanCounters         `json:"counters"`   // Counters of the monitor at the time of report.
+	Summary   ScanSummary          `json:"summary"`    // Outcome of topic checks of the reported topics.
+}
+
+// ClusterInfo contains the outcome of scanning a single cluster.
+type ClusterInfo struct {
+	Name             string        `json:"name"`              // Name of the cluster.
+	BootstrapServers []string      `json:"bootstrap_servers"` // Bootstrap servers of the cluster.
+	TopicNumber      int           `json:"topic_number"`      // Number of checked topics, including failed ones.
+	FailedTopics     int           `json:"failed_topics"`     // Number of topics failed to be checked.
+	SizeBytes        int64         `json:"size_bytes"`        // Size of checked topics on disks of the brokers.
+	ReclaimableBytes int64         `json:"reclaimable_bytes"` // Size of checked topics which are idle or empty.
+	Duration         time.Duration `json:"duration"`          // Time it took to scan the cluster.
+	Error            string        `json:"error,omitempty"`   // Error of listing topics, the cluster has no topics in the scan then.
+}
+
+// Cluster returns a copy of the scan with topics of the named cluster only.
+func (s *Scan) Cluster(name string) (*Scan, bool) {
+	for _, cluster := range s.Clusters {
+		if cluster.Name != name {
+			continue
+		}
+
+		scan := *s
+		scan.Clusters = []ClusterInfo{cluster}
+		scan.Topics = make([]*TopicActivityInfo, 0, cluster.TopicNumber)
+		for _, info := range s.Topics {
+			if info.Cluster == name {
+				scan.Topics = append(scan.Topics, info)
+			}
+		}
+		return &scan, true
+	}
+	return nil, false
+}
+
+// ScanSummary counts outcomes of topic checks of a scan.
+type ScanSummary struct {
+	Topics    int `json:"topics"`    // Number of checked topics.
+	Succeeded int `json:"succeeded"` // Number of topics checked successfully.
+	Failed    int `json:"failed"`    // Number of topics failed to be checked.
+}
+
+// Summarize counts outcomes of checks of the topics.
+func Summarize(topics []*TopicActivityInfo) ScanSummary {
+	summary := ScanSummary{Topics: len(topics)}
+	for _, info := range topics {
+		if info.Failed() {
+			summary.Failed++
+		}
+	}
+	summary.Succeeded = summary.Topics - summary.Failed
+	return summary
+}
+
+// ScanCounters contains cumulative counters of scans since the monitor started.
+type ScanCounters struct {
+	Scans       uint64 `json:"scans"`        // Number of completed scans.
+	ScanErrors  uint64 `json:"scan_errors"`  // Number of cluster scans failed to list topics.
+	TopicErrors uint64 `json:"topic_errors"` // Number of failed topic checks.
+}
+
 // TopicActivityInfo contains information about the last read and write operations for a topic.
 type TopicActivityInfo struct {
-	TopicName       string    // Name of the topic.
-	LastWriteTime   time.Time // Time when last message was written to any partition.
-	LastReadTime    time.Time // Time when message was consumed by any consumer group.
-	PartitionNumber int       // Number of partitions in topic.
-	Active          bool      // Indicates if the topic is active (has recent activity).
+	Cluster           string                  // Name of the cluster of the topic.
+	TopicName         string                  // Name of the topic.
+	LastWriteTime     time.Time               // Time when last message was written to any partition.
+	LastReadTime      time.Time               // Time when message was consumed by any consumer group.
+	PartitionNumber   int                     // Number of partitions in topic.
+	Status            TopicStatus             // Status of the topic computed from recency of writes and reads.
+	Active            bool                    // Indicates if the topic has recent writes or reads, see TopicStatus.Active.
+	InactivityRule    string                  // Name of the rule which classified the topic.
+	WriteInactivity   time.Duration           // Time without writes after which the rule considers writes not recent.
+	ReadInactivity    time.Duration           // Time without reads after which the rule considers reads not recent.
+	LastActiveTime    time.Time               // Time of the latest scan which found the topic active, kept in history.
+	FirstSeenTime     time.Time               // Time of the first scan which found the topic, Kafka doesn't expose creation time of topics.
+	Partitions        []PartitionActivityInfo // Per-partition breakdown of the activity.
+	ConsumerGroups    []ConsumerGroupInfo     // Lag of consumer groups that committed offsets for topic.
+	CheckDuration     time.Duration           // Time it took to check the topic.
+	RetentionMs       int64                   // Value of retention.ms of the topic, -1 for unlimited, zero if unknown.
+	RetentionBytes    int64                   // Value of retention.bytes of the topic, -1 for unlimited, zero if unknown.
+	CleanupPolicy     string                  // Value of cleanup.policy of the topic, e.g. delete or compact.
+	ReplicationFactor int                     // Number of replicas of partitions of the topic.
+	MinInSyncReplicas int                     // Value of min.insync.replicas of the topic.
+	SizeBytes         int64                   // Size of all replicas of the topic on disks of the brokers.
+	StorageCost       float64                 // Cost of storing the topic, size in GB times storage cost per GB of the cluster.
+	ReclaimableBytes  int64                   // Size of the topic if it is idle or empty, storage freed by deleting the topic.
+	Error             string                  // Error of the check, activity known from history only is reported then.
+	ErrorClass        ErrorClass              // Class of the error of the check, empty if the check succeeded.
+}
+
+// Failed tells if the check of the topic failed.
+func (t *TopicActivityInfo) Failed() bool {
+	return t.Error != ""
+}
+
+// ErrorClass classifies failures of topic checks by their cause.
+type ErrorClass string
+
+const (
+	ErrorClassTimeout            ErrorClass = "timeout"              // Kafka didn't respond in time.
+	ErrorClassAuthorization      ErrorClass = "authorization"        // Monitor is not allowed to describe or read the topic or its consumer groups.
+	ErrorClassLeaderNotAvailable ErrorClass = "leader-not-available" // Partition has no leader, e.g. during leader election or broker outage.
+	ErrorClassUnknownTopic       ErrorClass = "unknown-topic"        // Topic was deleted during the scan.
+	ErrorClassNetwork            ErrorClass = "network"              // Brokers are not reachable.
+	ErrorClassOther              ErrorClass = "other"                // Any other error.
+)
+
+// TopicStatus classifies a topic by recency of writes and reads.
+type TopicStatus string
+
+const (
+	StatusActive    TopicStatus = "active"     // Both writes and reads are recent.
+	StatusWriteOnly TopicStatus = "write-only" // Writes are recent, nobody reads them.
+	StatusReadOnly  TopicStatus = "read-only"  // Reads are recent, nothing is written.
+	StatusIdle      TopicStatus = "idle"       // Neither writes nor reads are recent.
+	StatusEmpty     TopicStatus = "empty"      // Topic has no messages and neither writes nor reads are recent.
+	StatusUnknown   TopicStatus = "unknown"    // Topic has messages but times of writes and reads are unknown.
+	StatusError     TopicStatus = "error"      // Check of the topic failed, see TopicActivityInfo.Error.
+	StatusTimeout   TopicStatus = "timeout"    // Check of the topic didn't complete in time.
+)
+
+// Active tells if the topic has recent writes or reads.
+func (s TopicStatus) Active() bool {
+	return s == StatusActive || s == StatusWriteOnly || s == StatusReadOnly
+}
+
+// Reclaimable tells if storage of the topic can be reclaimed, i.e. the topic is known to have neither recent
+// writes nor reads.
+func (s TopicStatus) Reclaimable() bool {
+	return s == StatusIdle || s == StatusEmpty
+}
+
+// PartitionActivityInfo contains offsets and activity of a single topic partition.
+type PartitionActivityInfo struct {
+	Partition        int32            // Partition id.
+	OldestOffset     int64            // Oldest available offset in partition.
+	NewestOffset     int64            // Offset of the next message to be written.
+	LastWriteTime    time.Time        // Timestamp of the last message written to partition, zero if the partition is empty.
+	MessageCount     int64            // Estimated number of messages, newest offset minus oldest offset.
+	SizeBytes        int64            // Size of all replicas of the partition on disks of the brokers.
+	Empty            bool             // Indicates if the partition holds no messages, or control records and records of aborted transactions only.
+	SearchDistance   int64            // Number of offsets walked back from newest offset to the last record, 1 if the last offset holds it, 0 if not searched.
+	CommittedOffsets map[string]int64 // Last committed offset per consumer group.
+}
+
+// Lags returns the lag per consumer group computed from committed offsets.
+func (p PartitionActivityInfo) Lags() map[string]int64 {
+	lags := make(map[string]int64, len(p.CommittedOffsets))
+	for group, offset := range p.CommittedOffsets {
+		lags[group] = Lag(p.NewestOffset, offset)
+	}
+	return lags
+}
+
+// Lag returns the number of messages between committed and newest offsets, committed offsets past newest
+// offset (e.g. after topic recreation) are treated as no lag.
+func Lag(newestOffset, committedOffset int64) int64 {
+	if committedOffset >= newestOffset {
+		return 0
+	}
+	return newestOffset - committedOffset
+}
+
+// ConsumerGroupInfo contains committed offsets and lag of a consumer group for a topic.
+type ConsumerGroupInfo struct {
+	GroupID    string             // Consumer group id.
+	TotalLag   int64              // Sum of lag across all partitions.
+	Partitions []PartitionLagInfo // Per-partition lag of the group.
+}
+
+// PartitionLagInfo contains the lag of a consumer group on a single partition.
+type PartitionLagInfo struct {
+	Partition       int32 // Partition id.
+	CommittedOffset int64 // Offset committed by the consumer group.
+	NewestOffset    int64 // Offset of the next message to be written.
+	Lag             int64 // Number of messages not yet consumed, newest offset minus committed offset.
 }
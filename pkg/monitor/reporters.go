@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported report format")
+)
+
+// ReporterRegistry holds reporters keyed by MIME type and by short format name used in ?format= parameter.
+type ReporterRegistry struct {
+	defaultMediaType string
+	reporters        map[string]registeredReporter
+	mediaTypes       map[string]string
+}
+
+// registeredReporter is a reporter with the full content type of its reports, including parameters.
+type registeredReporter struct {
+	contentType string
+	reporter    Reporter
+}
+
+func NewReporterRegistry() *ReporterRegistry {
+	return &ReporterRegistry{
+		reporters:  make(map[string]registeredReporter),
+		mediaTypes: make(map[string]string),
+	}
+}
+
+// Register adds reporter producing contentType, the first registered reporter is the default one.
+// Reporters are matched against the Accept header by the media type of contentType without parameters.
+func (r *ReporterRegistry) Register(format, contentType string, reporter Reporter) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %s of format %s: %w", contentType, format, err)
+	}
+
+	if r.defaultMediaType == "" {
+		r.defaultMediaType = mediaType
+	}
+	r.reporters[mediaType] = registeredReporter{contentType: contentType, reporter: reporter}
+	r.mediaTypes[format] = mediaType
+	return nil
+}
+
+// Reporter returns the reporter registered for the format name.
+func (r *ReporterRegistry) Reporter(format string) (string, Reporter, error) {
+	mediaType, ok := r.mediaTypes[format]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	return r.get(mediaType)
+}
+
+// Negotiate picks the reporter by format name if it is set, otherwise by the Accept header.
+// The default reporter is used if neither is set.
+func (r *ReporterRegistry) Negotiate(format, accept string) (string, Reporter, error) {
+	if format != "" {
+		return r.Reporter(format)
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return r.get(r.defaultMediaType)
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		switch {
+		case mediaRange == "*/*":
+			return r.get(r.defaultMediaType)
+		case strings.HasSuffix(mediaRange, "/*"):
+			// Prefer the default reporter if it matches the range.
+			prefix := strings.TrimSuffix(mediaRange, "*")
+			if strings.HasPrefix(r.defaultMediaType, prefix) {
+				return r.get(r.defaultMediaType)
+			}
+			for _, mediaType := range r.sortedMediaTypes() {
+				if strings.HasPrefix(mediaType, prefix) {
+					return r.get(mediaType)
+				}
+			}
+		default:
+			if _, ok := r.reporters[mediaRange]; ok {
+				return r.get(mediaRange)
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, accept)
+}
+
+// get returns content type and reporter registered for the media type.
+func (r *ReporterRegistry) get(mediaType string) (string, Reporter, error) {
+	registered, ok := r.reporters[mediaType]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, mediaType)
+	}
+	return registered.contentType, registered.reporter, nil
+}
+
+// sortedMediaTypes returns registered media types in stable order.
+func (r *ReporterRegistry) sortedMediaTypes() []string {
+	mediaTypes := make([]string, 0, len(r.reporters))
+	for mediaType := range r.reporters {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+// parseAccept returns media ranges of the Accept header sorted by quality, ranges with zero quality are dropped.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	mediaTypes := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		mediaTypes = append(mediaTypes, r.mediaType)
+	}
+	return mediaTypes
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestReporterRegistry_Negotiate(t *testing.T) {
+	csvReporter := report.NewCsvReporter()
+	jsonReporter := report.NewJson()
+
+	registry := NewReporterRegistry()
+	assert.NoError(t, registry.Register("csv", "text/csv", csvReporter))
+	assert.NoError(t, registry.Register("json", "application/json; charset=utf-8", jsonReporter))
+	assert.Error(t, registry.Register("broken", "text/", csvReporter))
+
+	tests := []struct {
+		name                string
+		format              string
+		accept              string
+		expectedContentType string
+		expectedReporter    Reporter
+		expectError         bool
+	}{
+		{name: "default", expectedContentType: "text/csv", expectedReporter: csvReporter},
+		{name: "format json", format: "json", expectedContentType: "application/json; charset=utf-8", expectedReporter: jsonReporter},
+		{name: "format wins over accept", format: "csv", accept: "application/json", expectedContentType: "text/csv", expectedReporter: csvReporter},
+		{name: "unknown format", format: "xml", expectError: true},
+		{name: "accept json", accept: "application/json", expectedContentType: "application/json; charset=utf-8", expectedReporter: jsonReporter},
+		{name: "accept any", accept: "*/*", expectedContentType: "text/csv", expectedReporter: csvReporter},
+		{name: "accept type wildcard", accept: "application/*", expectedContentType: "application/json; charset=utf-8", expectedReporter: jsonReporter},
+		{name: "accept with quality", accept: "text/csv;q=0.5, application/json", expectedContentType: "application/json; charset=utf-8", expectedReporter: jsonReporter},
+		{name: "accept skips unsupported", accept: "application/xml, text/csv;q=0.1", expectedContentType: "text/csv", expectedReporter: csvReporter},
+		{name: "accept zero quality", accept: "text/csv;q=0", expectError: true},
+		{name: "accept unsupported", accept: "application/xml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, reporter, err := registry.Negotiate(tt.format, tt.accept)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrUnsupportedFormat)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedContentType, contentType)
+			assert.Same(t, tt.expectedReporter, reporter)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultInactivityRule is the name of the rule applying inactivity days of the cluster to topics matching no rule.
+const DefaultInactivityRule = "default"
+
+var (
+	ErrEmptyRuleName          = errors.New("empty inactivity rule name")
+	ErrNonPositiveInactivity  = errors.New("inactivity must be positive")
+	ErrEmptyRuleTopicPatterns = errors.New("inactivity rule has no topic patterns")
+)
+
+// InactivityRule classifies topics matching any of its patterns, writes and reads are not recent after
+// WriteInactivity and ReadInactivity without them respectively.
+type InactivityRule struct {
+	Name            string
+	Clusters        []string // Clusters the rule applies to, all clusters if empty.
+	WriteInactivity time.Duration
+	ReadInactivity  time.Duration
+
+	topics []topicPattern
+}
+
+// NewInactivityRule compiles topic patterns of the rule, patterns enclosed in slashes are regular expressions, others are globs.
+func NewInactivityRule(name string, clusters, topics []string, writeInactivity, readInactivity time.Duration) (InactivityRule, error) {
+	if name == "" {
+		return InactivityRule{}, ErrEmptyRuleName
+	}
+	if writeInactivity <= 0 || readInactivity <= 0 {
+		return InactivityRule{}, fmt.Errorf("rule %s: %w", name, ErrNonPositiveInactivity)
+	}
+	if len(topics) == 0 {
+		return InactivityRule{}, fmt.Errorf("rule %s: %w", name, ErrEmptyRuleTopicPatterns)
+	}
+
+	patterns, err := newTopicPatterns(topics)
+	if err != nil {
+		return InactivityRule{}, fmt.Errorf("rule %s: %w", name, err)
+	}
+
+	return InactivityRule{
+		Name:            name,
+		Clusters:        clusters,
+		WriteInactivity: writeInactivity,
+		ReadInactivity:  readInactivity,
+		topics:          patterns,
+	}, nil
+}
+
+// Match tells if the rule applies to the topic of the cluster.
+func (r InactivityRule) Match(cluster, topic string) bool {
+	if len(r.Clusters) > 0 && !slices.Contains(r.Clusters, cluster) {
+		return false
+	}
+	return matchAny(r.topics, topic)
+}
+
+// inactivityRule returns the first rule matching the topic of the cluster or the default rule applying
+// inactivity days of the cluster to both writes and reads.
+func inactivityRule(rules []InactivityRule, cluster *Cluster, topic string) InactivityRule {
+	for _, rule := range rules {
+		if rule.Match(cluster.Name, topic) {
+			return rule
+		}
+	}
+	inactivity := time.Duration(cluster.InactivityDays) * 24 * time.Hour
+	return InactivityRule{
+		Name:            DefaultInactivityRule,
+		WriteInactivity: inactivity,
+		ReadInactivity:  inactivity,
+	}
+}
@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInactivityRule(t *testing.T) {
+	audit, err := NewInactivityRule("audit", nil, []string{"audit-*"}, 45*24*time.Hour, 45*24*time.Hour)
+	require.NoError(t, err)
+	events, err := NewInactivityRule("events", []string{"production"}, []string{`/^events\./`}, time.Minute, time.Hour)
+	require.NoError(t, err)
+	rules := []InactivityRule{audit, events}
+
+	production := &Cluster{Name: "production", InactivityDays: 7}
+	staging := &Cluster{Name: "staging", InactivityDays: 2}
+
+	tests := []struct {
+		name            string
+		cluster         *Cluster
+		topic           string
+		rule            string
+		writeInactivity time.Duration
+		readInactivity  time.Duration
+	}{
+		{"pattern match", production, "audit-logins", "audit", 45 * 24 * time.Hour, 45 * 24 * time.Hour},
+		{"regex match", production, "events.clicks", "events", time.Minute, time.Hour},
+		{"rule limited to other cluster", staging, "events.clicks", DefaultInactivityRule, 2 * 24 * time.Hour, 2 * 24 * time.Hour},
+		{"no match", production, "orders", DefaultInactivityRule, 7 * 24 * time.Hour, 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := inactivityRule(rules, tt.cluster, tt.topic)
+			assert.Equal(t, tt.rule, rule.Name)
+			assert.Equal(t, tt.writeInactivity, rule.WriteInactivity)
+			assert.Equal(t, tt.readInactivity, rule.ReadInactivity)
+		})
+	}
+}
+
+func TestNewInactivityRuleValidation(t *testing.T) {
+	_, err := NewInactivityRule("", nil, []string{"*"}, time.Hour, time.Hour)
+	assert.ErrorIs(t, err, ErrEmptyRuleName)
+
+	_, err = NewInactivityRule("audit", nil, []string{"*"}, time.Hour, 0)
+	assert.ErrorIs(t, err, ErrNonPositiveInactivity)
+
+	_, err = NewInactivityRule("audit", nil, nil, time.Hour, time.Hour)
+	assert.ErrorIs(t, err, ErrEmptyRuleTopicPatterns)
+
+	_, err = NewInactivityRule("audit", nil, []string{"/audit-(/"}, time.Hour, time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidTopicPattern)
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"time"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// topicStatus classifies the topic by recency of writes and reads against independent thresholds of the rule.
+// Topics without messages are empty unless writes or reads are recent, e.g. retention deleted the messages
+// written recently. Topics failed to be checked get timeout or error status whatever their activity known from history.
+func topicStatus(info *report.TopicActivityInfo, rule InactivityRule, now time.Time) report.TopicStatus {
+	if info.Failed() {
+		if info.ErrorClass == report.ErrorClassTimeout {
+			return report.StatusTimeout
+		}
+		return report.StatusError
+	}
+
+	if info.LastWriteTime.IsZero() && info.LastReadTime.IsZero() && hasMessages(info) {
+		return report.StatusUnknown
+	}
+
+	recentWrite := isRecent(info.LastWriteTime, rule.WriteInactivity, now)
+	recentRead := isRecent(info.LastReadTime, rule.ReadInactivity, now)
+	switch {
+	case recentWrite && recentRead:
+		return report.StatusActive
+	case recentWrite:
+		return report.StatusWriteOnly
+	case recentRead:
+		return report.StatusReadOnly
+	case !hasMessages(info):
+		return report.StatusEmpty
+	default:
+		return report.StatusIdle
+	}
+}
+
+// isRecent tells if t is known and less than inactivity before now.
+func isRecent(t time.Time, inactivity time.Duration, now time.Time) bool {
+	return !t.IsZero() && now.Sub(t) < inactivity
+}
+
+// hasMessages tells if any partition of the topic holds messages.
+func hasMessages(info *report.TopicActivityInfo) bool {
+	for _, partition := range info.Partitions {
+		if !partition.Empty {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestTopicStatus(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	week := InactivityRule{WriteInactivity: 7 * day, ReadInactivity: 7 * day}
+
+	tests := []struct {
+		name          string
+		lastWriteTime time.Time
+		lastReadTime  time.Time
+		empty         bool
+		errorClass    report.ErrorClass
+		rule          InactivityRule
+		expected      report.TopicStatus
+	}{
+		{
+			name:     "both timestamps zero, no messages",
+			empty:    true,
+			rule:     week,
+			expected: report.StatusEmpty,
+		},
+		{
+			name:     "both timestamps zero, has messages",
+			rule:     week,
+			expected: report.StatusUnknown,
+		},
+		{
+			name:          "both timestamps recent",
+			lastWriteTime: now.Add(-24 * time.Hour),
+			lastReadTime:  now.Add(-48 * time.Hour),
+			rule:          week,
+			expected:      report.StatusActive,
+		},
+		{
+			name:          "write recent, read old",
+			lastWriteTime: now.Add(-24 * time.Hour),
+			lastReadTime:  now.Add(-10 * day),
+			rule:          week,
+			expected:      report.StatusWriteOnly,
+		},
+		{
+			name:          "write old, read recent",
+			lastWriteTime: now.Add(-10 * day),
+			lastReadTime:  now.Add(-24 * time.Hour),
+			rule:          week,
+			expected:      report.StatusReadOnly,
+		},
+		{
+			name:          "both timestamps old",
+			lastWriteTime: now.Add(-10 * day),
+			lastReadTime:  now.Add(-10 * day),
+			rule:          week,
+			expected:      report.StatusIdle,
+		},
+		{
+			name:          "at exact boundary",
+			lastWriteTime: now.Add(-7 * day),
+			lastReadTime:  now.Add(-7 * day),
+			rule:          week,
+			expected:      report.StatusIdle,
+		},
+		{
+			name:          "just within boundary",
+			lastWriteTime: now.Add(-7*day + time.Minute),
+			lastReadTime:  now.Add(-7*day + time.Minute),
+			rule:          week,
+			expected:      report.StatusActive,
+		},
+		{
+			name:         "zero write, recent read",
+			lastReadTime: now.Add(-24 * time.Hour),
+			rule:         week,
+			expected:     report.StatusReadOnly,
+		},
+		{
+			name:          "recent write, zero read",
+			lastWriteTime: now.Add(-24 * time.Hour),
+			rule:          week,
+			expected:      report.StatusWriteOnly,
+		},
+		{
+			name:          "old write, zero read",
+			lastWriteTime: now.Add(-10 * day),
+			rule:          week,
+			expected:      report.StatusIdle,
+		},
+		{
+			name:         "zero write, old read",
+			lastReadTime: now.Add(-10 * day),
+			rule:         week,
+			expected:     report.StatusIdle,
+		},
+		{
+			name:          "old write, no messages",
+			lastWriteTime: now.Add(-10 * day),
+			empty:         true,
+			rule:          week,
+			expected:      report.StatusEmpty,
+		},
+		{
+			name:          "recent write, no messages",
+			lastWriteTime: now.Add(-24 * time.Hour),
+			empty:         true,
+			rule:          week,
+			expected:      report.StatusWriteOnly,
+		},
+		{
+			name:          "sub-day inactivity period",
+			lastWriteTime: now.Add(-2 * time.Minute),
+			lastReadTime:  now.Add(-2 * time.Minute),
+			rule:          InactivityRule{WriteInactivity: time.Minute, ReadInactivity: time.Minute},
+			expected:      report.StatusIdle,
+		},
+		{
+			name:          "independent write and read thresholds",
+			lastWriteTime: now.Add(-2 * time.Minute),
+			lastReadTime:  now.Add(-2 * day),
+			rule:          InactivityRule{WriteInactivity: time.Hour, ReadInactivity: 30 * day},
+			expected:      report.StatusActive,
+		},
+		{
+			name:          "longer inactivity period",
+			lastWriteTime: now.Add(-14 * day),
+			lastReadTime:  now.Add(-14 * day),
+			rule:          InactivityRule{WriteInactivity: 30 * day, ReadInactivity: 30 * day},
+			expected:      report.StatusActive,
+		},
+		{
+			name:          "zero inactivity period",
+			lastWriteTime: now.Add(-1 * time.Hour),
+			lastReadTime:  now.Add(-1 * time.Hour),
+			expected:      report.StatusIdle,
+		},
+		{
+			name:          "future timestamps",
+			lastWriteTime: now.Add(24 * time.Hour),
+			lastReadTime:  now.Add(24 * time.Hour),
+			rule:          week,
+			expected:      report.StatusActive,
+		},
+		{
+			name:          "check timed out, recent write in history",
+			lastWriteTime: now.Add(-24 * time.Hour),
+			errorClass:    report.ErrorClassTimeout,
+			rule:          week,
+			expected:      report.StatusTimeout,
+		},
+		{
+			name:       "check failed",
+			errorClass: report.ErrorClassAuthorization,
+			rule:       week,
+			expected:   report.StatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &report.TopicActivityInfo{
+				LastWriteTime: tt.lastWriteTime,
+				LastReadTime:  tt.lastReadTime,
+				Partitions:    []report.PartitionActivityInfo{{Empty: tt.empty}},
+				ErrorClass:    tt.errorClass,
+			}
+			if tt.errorClass != "" {
+				info.Error = "check failed"
+			}
+			assert.Equal(t, tt.expected, topicStatus(info, tt.rule, now))
+		})
+	}
+}
+
+func TestTopicStatusActive(t *testing.T) {
+	assert.True(t, report.StatusActive.Active())
+	assert.True(t, report.StatusWriteOnly.Active())
+	assert.True(t, report.StatusReadOnly.Active())
+	assert.False(t, report.StatusIdle.Active())
+	assert.False(t, report.StatusEmpty.Active())
+	assert.False(t, report.StatusUnknown.Active())
+	assert.False(t, report.StatusError.Active())
+	assert.False(t, report.StatusTimeout.Active())
+}
+
+func TestTopicStatusReclaimable(t *testing.T) {
+	assert.True(t, report.StatusIdle.Reclaimable())
+	assert.True(t, report.StatusEmpty.Reclaimable())
+	assert.False(t, report.StatusActive.Reclaimable())
+	assert.False(t, report.StatusWriteOnly.Reclaimable())
+	assert.False(t, report.StatusReadOnly.Reclaimable())
+	assert.False(t, report.StatusUnknown.Reclaimable())
+	assert.False(t, report.StatusError.Reclaimable())
+	assert.False(t, report.StatusTimeout.Reclaimable())
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"sort"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+	"kafka-topic-monitor/pkg/webhook"
+)
+
+// Notifier receives state transitions of topics found after every scan.
+type Notifier interface {
+	Notify([]webhook.Event)
+}
+
+// stateKey identifies a topic across clusters.
+type stateKey struct {
+	cluster string
+	topic   string
+}
+
+// topicStates holds the latest successfully checked state of every topic of clusters listed by any scan.
+// Transitions are found against it rather than against the previous scan, so a change of activity is reported
+// even if checks of the topic failed in the scans between.
+type topicStates struct {
+	listed map[string]bool // Clusters whose topics were listed by any scan.
+	topics map[stateKey]*report.TopicActivityInfo
+}
+
+func newTopicStates() *topicStates {
+	return &topicStates{
+		listed: make(map[string]bool),
+		topics: make(map[stateKey]*report.TopicActivityInfo),
+	}
+}
+
+// update compares topics found by the scan with their tracked states, returns topics which became active or
+// inactive, appeared or disappeared, and tracks the states found by the scan. Clusters whose topics could not be
+// listed by the scan are skipped, topics of a cluster listed for the first time are tracked without events.
+// States of topics whose check failed are kept, so their activity is compared once a check succeeds again.
+func (s *topicStates) update(scan *report.Scan) []webhook.Event {
+	compared := make(map[string]bool, len(scan.Clusters))
+	for _, cluster := range scan.Clusters {
+		if cluster.Error != "" {
+			continue
+		}
+		compared[cluster.Name] = s.listed[cluster.Name]
+		s.listed[cluster.Name] = true
+	}
+
+	var events []webhook.Event
+	found := make(map[stateKey]bool, len(scan.Topics))
+	for _, info := range scan.Topics {
+		known, listed := compared[info.Cluster]
+		if !listed {
+			continue
+		}
+		key := stateKey{cluster: info.Cluster, topic: info.TopicName}
+		found[key] = true
+		before, existed := s.topics[key]
+		if !existed || !info.Failed() {
+			s.topics[key] = info
+		}
+		if !known {
+			continue
+		}
+
+		event := webhook.Event{
+			Time:          scan.ScannedAt,
+			Cluster:       info.Cluster,
+			Topic:         info.TopicName,
+			Status:        info.Status,
+			LastWriteTime: info.LastWriteTime,
+			LastReadTime:  info.LastReadTime,
+		}
+		switch {
+		case !existed:
+			event.Type = webhook.EventTopicCreated
+		case info.Failed() || before.Failed() || info.Active == before.Active:
+			continue
+		case info.Active:
+			event.Type = webhook.EventTopicActive
+			event.PreviousStatus = before.Status
+		default:
+			event.Type = webhook.EventTopicInactive
+			event.PreviousStatus = before.Status
+		}
+		events = append(events, event)
+	}
+
+	// Tracked topics of listed clusters not found by the scan were deleted, report them sorted by cluster and topic.
+	var deleted []stateKey
+	for key := range s.topics {
+		if _, listed := compared[key.cluster]; listed && !found[key] {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		if deleted[i].cluster != deleted[j].cluster {
+			return deleted[i].cluster < deleted[j].cluster
+		}
+		return deleted[i].topic < deleted[j].topic
+	})
+	for _, key := range deleted {
+		before := s.topics[key]
+		delete(s.topics, key)
+		events = append(events, webhook.Event{
+			Type:           webhook.EventTopicDeleted,
+			Time:           scan.ScannedAt,
+			Cluster:        before.Cluster,
+			Topic:          before.TopicName,
+			PreviousStatus: before.Status,
+			LastWriteTime:  before.LastWriteTime,
+			LastReadTime:   before.LastReadTime,
+		})
+	}
+	return events
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+	"kafka-topic-monitor/pkg/webhook"
+)
+
+func TestTopicTransitions(t *testing.T) {
+	scannedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	topic := func(cluster, name string, status report.TopicStatus) *report.TopicActivityInfo {
+		return &report.TopicActivityInfo{Cluster: cluster, TopicName: name, Status: status, Active: status.Active()}
+	}
+	failed := topic("cluster-a", "failing", report.StatusError)
+	failed.Error = "timeout"
+
+	previous := &report.Scan{
+		Clusters: []report.ClusterInfo{{Name: "cluster-a"}, {Name: "cluster-b"}, {Name: "cluster-c", Error: "unreachable"}},
+		Topics: []*report.TopicActivityInfo{
+			topic("cluster-a", "waking", report.StatusIdle),
+			topic("cluster-a", "sleeping", report.StatusActive),
+			topic("cluster-a", "steady", report.StatusWriteOnly),
+			topic("cluster-a", "failing", report.StatusActive),
+			topic("cluster-a", "removed", report.StatusEmpty),
+			topic("cluster-b", "orders", report.StatusActive),
+		},
+	}
+	current := &report.Scan{
+		ScannedAt: scannedAt,
+		Clusters:  []report.ClusterInfo{{Name: "cluster-a"}, {Name: "cluster-b", Error: "unreachable"}, {Name: "cluster-c"}},
+		Topics: []*report.TopicActivityInfo{
+			topic("cluster-a", "waking", report.StatusReadOnly),
+			topic("cluster-a", "sleeping", report.StatusIdle),
+			topic("cluster-a", "steady", report.StatusActive),
+			failed,
+			topic("cluster-a", "added", report.StatusEmpty),
+			topic("cluster-c", "payments", report.StatusActive),
+		},
+	}
+
+	states := newTopicStates()
+	// Topics of clusters listed for the first time are tracked without events.
+	assert.Empty(t, states.update(previous))
+	events := states.update(current)
+	assert.Equal(t, []webhook.Event{
+		{Type: webhook.EventTopicActive, Time: scannedAt, Cluster: "cluster-a", Topic: "waking", Status: report.StatusReadOnly, PreviousStatus: report.StatusIdle},
+		{Type: webhook.EventTopicInactive, Time: scannedAt, Cluster: "cluster-a", Topic: "sleeping", Status: report.StatusIdle, PreviousStatus: report.StatusActive},
+		{Type: webhook.EventTopicCreated, Time: scannedAt, Cluster: "cluster-a", Topic: "added", Status: report.StatusEmpty},
+		{Type: webhook.EventTopicDeleted, Time: scannedAt, Cluster: "cluster-a", Topic: "removed", PreviousStatus: report.StatusEmpty},
+	}, events)
+
+	assert.Empty(t, states.update(current))
+}
+
+func TestTopicTransitionsAcrossFailedChecks(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clusters := []report.ClusterInfo{{Name: "cluster-a"}}
+	scan := func(hour int, status report.TopicStatus, err string) *report.Scan {
+		return &report.Scan{
+			ScannedAt: start.Add(time.Duration(hour) * time.Hour),
+			Clusters:  clusters,
+			Topics: []*report.TopicActivityInfo{
+				{Cluster: "cluster-a", TopicName: "orders", Status: status, Active: status.Active(), Error: err},
+			},
+		}
+	}
+
+	states := newTopicStates()
+	assert.Empty(t, states.update(scan(0, report.StatusActive, "")))
+	assert.Empty(t, states.update(scan(1, report.StatusError, "timeout")))
+	// The topic became idle while its check was failing, it is compared with the last successful check.
+	assert.Equal(t, []webhook.Event{
+		{Type: webhook.EventTopicInactive, Time: start.Add(2 * time.Hour), Cluster: "cluster-a", Topic: "orders", Status: report.StatusIdle, PreviousStatus: report.StatusActive},
+	}, states.update(scan(2, report.StatusIdle, "")))
+
+	assert.Empty(t, states.update(scan(3, report.StatusError, "timeout")))
+	assert.Equal(t, []webhook.Event{
+		{Type: webhook.EventTopicActive, Time: start.Add(4 * time.Hour), Cluster: "cluster-a", Topic: "orders", Status: report.StatusActive, PreviousStatus: report.StatusIdle},
+	}, states.update(scan(4, report.StatusActive, "")))
+}
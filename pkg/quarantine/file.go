@@ -0,0 +1,150 @@
+package quarantine
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Entry remembers a quarantined topic and the configs it had before the quarantine.
+type Entry struct {
+	Cluster       string             `json:"cluster"`        // Name of the cluster of the topic.
+	Topic         string             `json:"topic"`          // Name of the topic.
+	QuarantinedAt time.Time          `json:"quarantined_at"` // Time when the topic was quarantined.
+	Configs       map[string]string  `json:"configs"`        // Configs set by the quarantine.
+	Original      map[string]*string `json:"original"`       // Values of the configs before the quarantine, null if they were not set on the topic.
+}
+
+// topicKey identifies a topic across clusters.
+type topicKey struct {
+	cluster string
+	topic   string
+}
+
+// FileStore keeps quarantined topics in a JSON file, the file is replaced on every change.
+type FileStore struct {
+	mu       sync.Mutex
+	fileName string
+	entries  map[topicKey]Entry
+}
+
+// NewFileStore opens the quarantine file, a missing file holds no quarantined topics.
+func NewFileStore(fileName string) (*FileStore, error) {
+	store := &FileStore{fileName: fileName, entries: make(map[topicKey]Entry)}
+
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine file %s: %w", fileName, err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode quarantine file %s: %w", fileName, err)
+	}
+	for _, entry := range entries {
+		store.entries[topicKey{cluster: entry.Cluster, topic: entry.Topic}] = entry
+	}
+	return store, nil
+}
+
+// Add remembers the quarantined topic, replacing an earlier entry of the topic.
+func (s *FileStore) Add(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := topicKey{cluster: entry.Cluster, topic: entry.Topic}
+	previous, existed := s.entries[key]
+	s.entries[key] = entry
+	if err := s.save(); err != nil {
+		if existed {
+			s.entries[key] = previous
+		} else {
+			delete(s.entries, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove forgets the quarantined topic.
+func (s *FileStore) Remove(cluster, topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := topicKey{cluster: cluster, topic: topic}
+	entry, existed := s.entries[key]
+	if !existed {
+		return nil
+	}
+	delete(s.entries, key)
+	if err := s.save(); err != nil {
+		s.entries[key] = entry
+		return err
+	}
+	return nil
+}
+
+// Get returns the entry of the quarantined topic.
+func (s *FileStore) Get(cluster, topic string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[topicKey{cluster: cluster, topic: topic}]
+	return entry, ok
+}
+
+// List returns all quarantined topics ordered by cluster and topic.
+func (s *FileStore) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedEntries()
+}
+
+// sortedEntries returns all entries ordered by cluster and topic.
+func (s *FileStore) sortedEntries() []Entry {
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.Topic, b.Topic))
+	})
+	return entries
+}
+
+// save writes all entries to a temporary file and renames it over the quarantine file, so the file is never partial.
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.sortedEntries(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine entries: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.fileName), filepath.Base(s.fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write quarantine file %s: %w", s.fileName, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write quarantine file %s: %w", s.fileName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync quarantine file %s: %w", s.fileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write quarantine file %s: %w", s.fileName, err)
+	}
+	if err := os.Rename(tmp.Name(), s.fileName); err != nil {
+		return fmt.Errorf("failed to write quarantine file %s: %w", s.fileName, err)
+	}
+	return nil
+}
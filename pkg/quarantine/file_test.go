@@ -0,0 +1,42 @@
+package quarantine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "quarantine.json")
+	quarantinedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	retention := "604800000"
+
+	store, err := NewFileStore(fileName)
+	require.NoError(t, err)
+	assert.Empty(t, store.List())
+
+	orders := Entry{Cluster: "cluster-b", Topic: "orders", QuarantinedAt: quarantinedAt, Configs: map[string]string{"retention.ms": "86400000"}, Original: map[string]*string{"retention.ms": &retention}}
+	payments := Entry{Cluster: "cluster-a", Topic: "payments", QuarantinedAt: quarantinedAt, Configs: map[string]string{"retention.ms": "86400000"}, Original: map[string]*string{"retention.ms": nil}}
+	require.NoError(t, store.Add(orders))
+	require.NoError(t, store.Add(payments))
+
+	// Entries survive reopening the store.
+	store, err = NewFileStore(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{payments, orders}, store.List())
+	entry, ok := store.Get("cluster-a", "payments")
+	require.True(t, ok)
+	assert.Nil(t, entry.Original["retention.ms"])
+
+	require.NoError(t, store.Remove("cluster-a", "payments"))
+	require.NoError(t, store.Remove("cluster-a", "unknown"))
+	_, ok = store.Get("cluster-a", "payments")
+	assert.False(t, ok)
+
+	store, err = NewFileStore(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{orders}, store.List())
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	. "kafka-topic-monitor/pkg/logger"
+)
+
+// notifyQueueSize is the number of events waiting to be sent, further events are dropped.
+const notifyQueueSize = 1000
+
+var (
+	ErrUnexpectedStatus = errors.New("webhook responded with unexpected status")
+)
+
+// Notifier sends events to webhooks in the background, failed deliveries are retried with exponential backoff.
+type Notifier struct {
+	webhooks   []Webhook
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+
+	events chan Event
+}
+
+// NewNotifier creates a notifier sending events to the webhooks, client defaults to http.DefaultClient.
+// Deliveries are retried up to maxRetries times, waiting backoff before the first retry and doubling it on every other.
+func NewNotifier(webhooks []Webhook, client *http.Client, maxRetries int, backoff time.Duration) *Notifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Notifier{
+		webhooks:   webhooks,
+		client:     client,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		events:     make(chan Event, notifyQueueSize),
+	}
+}
+
+// Notify queues the events to be sent by Run without blocking, events are dropped when the queue is full.
+func (n *Notifier) Notify(events []Event) {
+	for _, event := range events {
+		select {
+		case n.events <- event:
+		default:
+			GetLogger().Warnf("Dropping %s event of topic %s of cluster %s, webhook queue is full", event.Type, event.Topic, event.Cluster)
+		}
+	}
+}
+
+// Run sends queued events to webhooks accepting them until ctx is done.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-n.events:
+			for _, webhook := range n.webhooks {
+				if !webhook.Accepts(event.Type) {
+					continue
+				}
+				if err := n.send(ctx, webhook, event); err != nil {
+					GetLogger().Errorf("failed to send %s event of topic %s of cluster %s to webhook %s: %v", event.Type, event.Topic, event.Cluster, webhook.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// send posts the event to the webhook, retrying network errors, server errors and rate limiting.
+func (n *Notifier) send(ctx context.Context, webhook Webhook, event Event) error {
+	payload, err := webhook.Payload(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, webhook.URL, payload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.maxRetries {
+			return err
+		}
+		GetLogger().Debugf("Retrying webhook %s in %s: %v", webhook.Name, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the payload to url and tells if a failed delivery should be retried.
+func (n *Notifier) post(ctx context.Context, url string, payload []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("%w: %s", ErrUnexpectedStatus, response.Status)
+}
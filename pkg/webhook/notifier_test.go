@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifierSend(t *testing.T) {
+	var (
+		attempts atomic.Int32
+		statuses = []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}
+		received = make(chan Event, 1)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		status := statuses[attempts.Add(1)-1]
+		if status == http.StatusOK {
+			var event Event
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+			received <- event
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hook, err := NewWebhook("ops", server.URL, FormatJSON, "", []string{EventTopicDeleted})
+	require.NoError(t, err)
+	notifier := NewNotifier([]Webhook{hook}, server.Client(), 2, time.Millisecond)
+	go notifier.Run(ctx)
+
+	// Events the webhook doesn't accept are not sent.
+	notifier.Notify([]Event{
+		{Type: EventTopicCreated, Cluster: "cluster-a", Topic: "orders"},
+		{Type: EventTopicDeleted, Cluster: "cluster-a", Topic: "payments"},
+	})
+	select {
+	case event := <-received:
+		assert.Equal(t, "payments", event.Topic)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestNotifierSendGivesUp(t *testing.T) {
+	var attempts atomic.Int32
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hook, err := NewWebhook("ops", server.URL, FormatSlack, "", nil)
+	require.NoError(t, err)
+	notifier := NewNotifier([]Webhook{hook}, server.Client(), 2, time.Millisecond)
+	event := Event{Type: EventTopicCreated, Cluster: "cluster-a", Topic: "orders"}
+
+	// Client errors are not retried.
+	err = notifier.send(context.Background(), hook, event)
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, int32(1), attempts.Load())
+
+	// Server errors are retried up to max retries.
+	status = http.StatusServiceUnavailable
+	attempts.Store(0)
+	err = notifier.send(context.Background(), hook, event)
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, int32(3), attempts.Load())
+}
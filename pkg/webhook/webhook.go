@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+// Types of events emitted on state transitions of topics between scans.
+const (
+	EventTopicActive   = "topic-active"   // Inactive topic became active.
+	EventTopicInactive = "topic-inactive" // Active topic became inactive.
+	EventTopicCreated  = "topic-created"  // Topic appeared since the previous scan.
+	EventTopicDeleted  = "topic-deleted"  // Topic disappeared since the previous scan.
+)
+
+// Payload formats of webhooks.
+const (
+	FormatJSON      = "json"      // Event as JSON.
+	FormatSlack     = "slack"     // Slack incoming webhook message.
+	FormatPagerDuty = "pagerduty" // PagerDuty Events API v2 event.
+)
+
+var (
+	ErrUnknownFormat     = errors.New("unknown webhook format")
+	ErrUnknownEvent      = errors.New("unknown webhook event")
+	ErrMissingURL        = errors.New("webhook requires a URL")
+	ErrMissingRoutingKey = errors.New("pagerduty webhook requires a routing key")
+)
+
+// eventTypes lists all types of events.
+var eventTypes = []string{EventTopicActive, EventTopicInactive, EventTopicCreated, EventTopicDeleted}
+
+// Event is a state transition of a topic found by comparing two scans.
+type Event struct {
+	Type           string             `json:"type"`                      // Type of the transition.
+	Time           time.Time          `json:"time"`                      // Time when the scan which found the transition started.
+	Cluster        string             `json:"cluster"`                   // Name of the cluster of the topic.
+	Topic          string             `json:"topic"`                     // Name of the topic.
+	Status         report.TopicStatus `json:"status,omitempty"`          // Status of the topic, empty for deleted topics.
+	PreviousStatus report.TopicStatus `json:"previous_status,omitempty"` // Status of the topic in the previous scan, empty for created topics.
+	LastWriteTime  time.Time          `json:"last_write_time"`           // Time when last message was written to the topic.
+	LastReadTime   time.Time          `json:"last_read_time"`            // Time when a message of the topic was last consumed.
+}
+
+// Summary describes the event in a single line.
+func (e Event) Summary() string {
+	switch e.Type {
+	case EventTopicActive:
+		return fmt.Sprintf("Topic %s of cluster %s became active (%s, was %s)", e.Topic, e.Cluster, e.Status, e.PreviousStatus)
+	case EventTopicInactive:
+		return fmt.Sprintf("Topic %s of cluster %s became inactive (%s, was %s)", e.Topic, e.Cluster, e.Status, e.PreviousStatus)
+	case EventTopicCreated:
+		return fmt.Sprintf("Topic %s of cluster %s appeared (%s)", e.Topic, e.Cluster, e.Status)
+	case EventTopicDeleted:
+		return fmt.Sprintf("Topic %s of cluster %s disappeared (was %s)", e.Topic, e.Cluster, e.PreviousStatus)
+	default:
+		return fmt.Sprintf("Topic %s of cluster %s: %s", e.Topic, e.Cluster, e.Type)
+	}
+}
+
+// Webhook is an HTTP endpoint receiving events of the selected types in its payload format.
+type Webhook struct {
+	Name       string
+	URL        string
+	Format     string
+	RoutingKey string   // Integration key of PagerDuty webhooks.
+	Events     []string // Types of events sent to the webhook, all types if empty.
+}
+
+// NewWebhook validates the webhook, format defaults to JSON.
+func NewWebhook(name, url, format, routingKey string, events []string) (Webhook, error) {
+	if url == "" {
+		return Webhook{}, fmt.Errorf("%w: %s", ErrMissingURL, name)
+	}
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatSlack:
+	case FormatPagerDuty:
+		if routingKey == "" {
+			return Webhook{}, fmt.Errorf("%w: %s", ErrMissingRoutingKey, name)
+		}
+	default:
+		return Webhook{}, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	for _, event := range events {
+		if !slices.Contains(eventTypes, event) {
+			return Webhook{}, fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+	}
+	return Webhook{Name: name, URL: url, Format: format, RoutingKey: routingKey, Events: events}, nil
+}
+
+// Accepts reports if events of the type are sent to the webhook.
+func (w Webhook) Accepts(eventType string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+// Payload encodes the event in the format of the webhook.
+func (w Webhook) Payload(event Event) ([]byte, error) {
+	switch w.Format {
+	case FormatSlack:
+		return json.Marshal(slackMessage{Text: event.Summary()})
+	case FormatPagerDuty:
+		return json.Marshal(pagerDutyPayload(w.RoutingKey, event))
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// slackMessage is a message of Slack incoming webhooks.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// pagerDutyEvent is an event of PagerDuty Events API v2.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyDetails `json:"payload,omitempty"`
+}
+
+// pagerDutyDetails describes the alert of a PagerDuty trigger event.
+type pagerDutyDetails struct {
+	Summary       string    `json:"summary"`
+	Source        string    `json:"source"`
+	Severity      string    `json:"severity"`
+	Timestamp     time.Time `json:"timestamp"`
+	Component     string    `json:"component"`
+	Class         string    `json:"class"`
+	CustomDetails Event     `json:"custom_details"`
+}
+
+// pagerDutyPayload triggers an alert for inactive, created and deleted topics, the alert of an inactive topic
+// is resolved when the topic becomes active again. Alerts are deduplicated by cluster, topic and type.
+func pagerDutyPayload(routingKey string, event Event) pagerDutyEvent {
+	dedupKey := event.Cluster + "/" + event.Topic + "/" + event.Type
+	if event.Type == EventTopicActive {
+		dedupKey = event.Cluster + "/" + event.Topic + "/" + EventTopicInactive
+		return pagerDutyEvent{RoutingKey: routingKey, EventAction: "resolve", DedupKey: dedupKey}
+	}
+
+	severity := "info"
+	if event.Type == EventTopicInactive {
+		severity = "warning"
+	}
+	return pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey,
+		Payload: &pagerDutyDetails{
+			Summary:       event.Summary(),
+			Source:        event.Cluster,
+			Severity:      severity,
+			Timestamp:     event.Time,
+			Component:     event.Topic,
+			Class:         event.Type,
+			CustomDetails: event,
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kafka-topic-monitor/pkg/monitor/report"
+)
+
+func TestNewWebhook(t *testing.T) {
+	hook, err := NewWebhook("ops", "http://localhost/hook", "", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, hook.Format)
+	assert.True(t, hook.Accepts(EventTopicDeleted))
+
+	hook, err = NewWebhook("ops", "http://localhost/hook", FormatSlack, "", []string{EventTopicInactive})
+	require.NoError(t, err)
+	assert.True(t, hook.Accepts(EventTopicInactive))
+	assert.False(t, hook.Accepts(EventTopicCreated))
+
+	_, err = NewWebhook("ops", "", FormatJSON, "", nil)
+	assert.ErrorIs(t, err, ErrMissingURL)
+	_, err = NewWebhook("ops", "http://localhost/hook", "xml", "", nil)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = NewWebhook("ops", "http://localhost/hook", FormatPagerDuty, "", nil)
+	assert.ErrorIs(t, err, ErrMissingRoutingKey)
+	_, err = NewWebhook("ops", "http://localhost/hook", FormatJSON, "", []string{"topic-renamed"})
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+}
+
+func TestWebhookPayload(t *testing.T) {
+	event := Event{
+		Type:           EventTopicInactive,
+		Time:           time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Cluster:        "cluster-a",
+		Topic:          "orders",
+		Status:         report.StatusIdle,
+		PreviousStatus: report.StatusActive,
+	}
+
+	payload, err := Webhook{Format: FormatJSON}.Payload(event)
+	require.NoError(t, err)
+	var decoded Event
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, event, decoded)
+
+	payload, err = Webhook{Format: FormatSlack}.Payload(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "Topic orders of cluster cluster-a became inactive (idle, was active)"}`, string(payload))
+
+	pagerDuty := Webhook{Format: FormatPagerDuty, RoutingKey: "key"}
+	payload, err = pagerDuty.Payload(event)
+	require.NoError(t, err)
+	var trigger pagerDutyEvent
+	require.NoError(t, json.Unmarshal(payload, &trigger))
+	assert.Equal(t, "trigger", trigger.EventAction)
+	assert.Equal(t, "key", trigger.RoutingKey)
+	assert.Equal(t, "cluster-a/orders/topic-inactive", trigger.DedupKey)
+	require.NotNil(t, trigger.Payload)
+	assert.Equal(t, "warning", trigger.Payload.Severity)
+	assert.Equal(t, "cluster-a", trigger.Payload.Source)
+
+	// The topic becoming active again resolves the alert of its inactivity.
+	event.Type, event.Status, event.PreviousStatus = EventTopicActive, report.StatusActive, report.StatusIdle
+	payload, err = pagerDuty.Payload(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"routing_key": "key", "event_action": "resolve", "dedup_key": "cluster-a/orders/topic-inactive"}`, string(payload))
+}